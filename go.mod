@@ -0,0 +1,5 @@
+module github.com/pastaTree/goExercise
+
+go 1.20
+
+require github.com/go-sql-driver/mysql v1.7.1
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	iniparser "github.com/pastaTree/goExercise/pkg/iniParser"
+	"os"
+)
+
+func main() {
+	// 子命令, 见 tool.go
+	if len(os.Args) > 1 {
+		run, ok := commands[os.Args[1]]
+		if !ok {
+			usage()
+			os.Exit(2)
+		}
+		if err := run(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[1], err)
+			os.Exit(1)
+		}
+		return
+	}
+	var cfg iniparser.Config
+	err := iniparser.LoadIni("./config.ini", &cfg)
+	if err != nil {
+		fmt.Printf("load config ini failed, error: %v\n", err)
+		return
+	}
+	// 密码等敏感字段不打印出来
+	fmt.Printf("%#v\n", iniparser.Redacted(cfg))
+}
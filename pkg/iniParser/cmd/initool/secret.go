@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	iniparser "github.com/pastaTree/goExercise/pkg/iniParser"
+	"io"
+	"os"
+	"strings"
+)
+
+// runGenKey genkey 子命令, 输出一个新的密钥
+func runGenKey(args []string) error {
+	key, err := iniparser.GenerateKey()
+	if err != nil {
+		return err
+	}
+	fmt.Println(key)
+	return nil
+}
+
+// runEncrypt encrypt 子命令, 输出加密后的值
+func runEncrypt(args []string) error {
+	fs := flag.NewFlagSet("encrypt", flag.ContinueOnError)
+	keyFile := fs.String("keyfile", "", "file containing the base64 key")
+	keyEnv := fs.String("keyenv", iniparser.DefaultKeyEnv, "environment variable containing the base64 key, used when -keyfile is empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var provider iniparser.KeyProvider = iniparser.KeyEnv(*keyEnv)
+	if len(*keyFile) > 0 {
+		provider = iniparser.KeyFile(*keyFile)
+	}
+	var plaintext string
+	switch fs.NArg() {
+	case 0:
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		plaintext = strings.TrimRight(string(b), "\r\n")
+	case 1:
+		plaintext = fs.Arg(0)
+	default:
+		return errors.New("usage: encrypt [-keyfile file | -keyenv NAME] [plaintext]")
+	}
+	value, err := iniparser.EncryptValue(plaintext, provider)
+	if err != nil {
+		return err
+	}
+	fmt.Println(value)
+	return nil
+}
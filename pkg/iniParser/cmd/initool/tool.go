@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	iniparser "github.com/pastaTree/goExercise/pkg/iniParser"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 命令行工具, 编译成 initool 后给部署脚本使用, 代替 grep 和 sed
+//   go build -o initool ./cmd/initool
+//   initool validate [-schema config] [-profile prod] file.ini...   检查语法, 给出 schema 时按结构体严格检查
+//   initool get [-profile prod] file.ini mysql.port                 输出展开后的值
+//   initool set [-raw] file.ini redis.host 10.0.0.1                 修改或添加键, 保留注释和格式
+//   initool fmt [-w] [file.ini...]                                  格式化, 见 iniparser.Document.Format
+//   initool diff [-profile prod] a.ini b.ini                        按 section.key 比较两个文件
+//   initool genkey, initool encrypt                                 生成密钥和加密值, 见 secret.go
+//   initool sample, initool docs                                    输出 Config 的示例文件和 Markdown 说明
+// 键写成 section.key, 全局的键只写 key, 嵌套的节写成 mysql.replica.port
+// 出错或 diff 发现不同时退出码为 1
+
+// commands 子命令, main 的第一个参数是子命令名时执行对应的函数
+var commands = map[string]func(args []string) error{
+	"validate": runValidate,
+	"get": runGet,
+	"set": runSet,
+	"fmt": runFmt,
+	"diff": runDiff,
+	"genkey": runGenKey,
+	"encrypt": runEncrypt,
+	"sample": func([]string) error { return iniparser.WriteSample(os.Stdout, &iniparser.Config{}) },
+	"docs": func([]string) error { return iniparser.WriteMarkdown(os.Stdout, &iniparser.Config{}) },
+}
+
+// schemas validate -schema 可以使用的结构体
+var schemas = map[string]func() interface{}{
+	"config": func() interface{} { return new(iniparser.Config) },
+}
+
+// usage 输出所有子命令
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(os.Stderr, "usage: %s [command] [arguments]\n", filepath.Base(os.Args[0]))
+	fmt.Fprintf(os.Stderr, "commands: %s\n", strings.Join(names, ", "))
+	fmt.Fprintln(os.Stderr, "without a command, ./config.ini is loaded and printed")
+}
+
+// runValidate validate 子命令
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	schema := fs.String("schema", "", "check keys and values against a struct: config")
+	profile := fs.String("profile", "", "profile used to select [section@profile]")
+	keyFile := fs.String("keyfile", "", "file containing the key for encrypted secret values")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("usage: validate [-schema name] [-profile name] [-keyfile file] file.ini...")
+	}
+	var newConfig func() interface{}
+	if len(*schema) > 0 {
+		var ok bool
+		if newConfig, ok = schemas[*schema]; !ok {
+			return fmt.Errorf("unknown schema %s", *schema)
+		}
+	}
+	opts := iniparser.Options{Strict: true, Profile: *profile}
+	if len(*keyFile) > 0 {
+		opts.Keys = iniparser.KeyFile(*keyFile)
+	}
+	failed := 0
+	for _, name := range fs.Args() {
+		var err error
+		if newConfig != nil {
+			err = iniparser.LoadIni(name, newConfig(), opts)
+		} else {
+			_, err = iniparser.LoadDocument(name, opts)
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			continue
+		}
+		fmt.Printf("%s: ok\n", name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) failed", failed)
+	}
+	return nil
+}
+
+// runGet get 子命令
+func runGet(args []string) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	profile := fs.String("profile", "", "profile used to select [section@profile]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("usage: get [-profile name] file.ini section.key")
+	}
+	doc, err := iniparser.LoadDocument(fs.Arg(0), iniparser.Options{Profile: *profile})
+	if err != nil {
+		return err
+	}
+	value, ok, err := doc.Lookup(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s not found", fs.Arg(1))
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// runSet set 子命令, 只修改给出的文件, 不修改 include 的文件
+// 值按字面写入, $ 和 % 写成 $$ 和 %%, -raw 时原样写入, 可以写 ${VAR} 这样的变量
+func runSet(args []string) error {
+	fs := flag.NewFlagSet("set", flag.ContinueOnError)
+	raw := fs.Bool("raw", false, "write the value as is, so ${VAR} and %(key)s are expanded when loading")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 3 {
+		return errors.New("usage: set [-raw] file.ini section.key value")
+	}
+	fileName := fs.Arg(0)
+	doc, err := readFileDocument(fileName)
+	if err != nil {
+		return err
+	}
+	value := fs.Arg(2)
+	if !*raw {
+		value = iniparser.EscapeValue(value)
+	}
+	doc.SetPath(fs.Arg(1), value)
+	return writeFileAtomic(fileName, doc.Bytes())
+}
+
+// runFmt fmt 子命令, 没有给出文件时格式化标准输入
+func runFmt(args []string) error {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := fs.Bool("w", false, "write result to the file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		var buf bytes.Buffer
+		if _, err := buf.ReadFrom(os.Stdin); err != nil {
+			return err
+		}
+		doc, err := iniparser.ParseDocument(buf.Bytes())
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(doc.Format())
+		return err
+	}
+	for _, name := range fs.Args() {
+		doc, err := readFileDocument(name)
+		if err != nil {
+			return err
+		}
+		formatted := doc.Format()
+		if !*write {
+			if _, err = os.Stdout.Write(formatted); err != nil {
+				return err
+			}
+			continue
+		}
+		if bytes.Equal(formatted, doc.Bytes()) {
+			continue
+		}
+		if err = writeFileAtomic(name, formatted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runDiff diff 子命令, 比较 include 和继承展开后的值, 不展开变量, 敏感的值用 ****** 代替
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	profile := fs.String("profile", "", "profile used to select [section@profile]")
+	showSecrets := fs.Bool("show-secrets", false, "print values of password-like keys")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("usage: diff [-profile name] [-show-secrets] a.ini b.ini")
+	}
+	var keys [2]map[string]string
+	for i, name := range fs.Args() {
+		doc, err := iniparser.LoadDocument(name, iniparser.Options{Profile: *profile})
+		if err != nil {
+			return err
+		}
+		keys[i] = doc.Flatten()
+	}
+	changes := iniparser.DiffKeys(keys[0], keys[1])
+	for _, c := range changes {
+		if !*showSecrets && iniparser.IsSensitiveKey(c.Key[strings.LastIndex(c.Key, ".")+1:]) {
+			c.Old, c.New = redactString(c.Old), redactString(c.New)
+		}
+		_, inOld := keys[0][c.Key]
+		_, inNew := keys[1][c.Key]
+		switch {
+		case !inOld:
+			fmt.Printf("+ %s=%s\n", c.Key, c.New)
+		case !inNew:
+			fmt.Printf("- %s=%s\n", c.Key, c.Old)
+		default:
+			fmt.Printf("~ %s: %s -> %s\n", c.Key, c.Old, c.New)
+		}
+	}
+	if len(changes) > 0 {
+		return fmt.Errorf("%d key(s) differ", len(changes))
+	}
+	return nil
+}
+
+// redactString 隐藏非空的字符串
+func redactString(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+	return iniparser.RedactedValue
+}
+
+// readFileDocument 读取并解析单个文件, 不处理 include, 用于修改后写回, 只支持 ini 格式
+func readFileDocument(fileName string) (*iniparser.Document, error) {
+	if iniparser.FormatOf(fileName) != "ini" {
+		return nil, fmt.Errorf("%s: only ini files can be rewritten", fileName)
+	}
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := iniparser.ParseDocument(b)
+	if err != nil {
+		if pe, ok := err.(*iniparser.ParseError); ok {
+			pe.File = fileName
+		}
+		return nil, err
+	}
+	for _, sec := range doc.Sections() {
+		sec.File = fileName
+	}
+	return doc, nil
+}
+
+// writeFileAtomic 先写临时文件再改名, 中途失败时原文件不变, 文件权限保持不变
+func writeFileAtomic(fileName string, b []byte) error {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(fileName), "."+filepath.Base(fileName)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(b); err == nil {
+		err = tmp.Chmod(info.Mode().Perm())
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fileName)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig 在临时目录中写一个配置文件
+func writeConfig(t *testing.T, name, content string, perm os.FileMode) string {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(fileName, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+	return fileName
+}
+
+// TestRunSet 值按字面写入, -raw 原样写入, 注释和文件权限不变
+func TestRunSet(t *testing.T) {
+	fileName := writeConfig(t, "config.ini", "; app\n[mysql]\nport=3306 ; default\n", 0600)
+	if err := runSet([]string{fileName, "mysql.port", "3307"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := runSet([]string{fileName, "mysql.password", "p$w%d"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := runSet([]string{"-raw", fileName, "redis.password", "${REDIS_PASSWORD}"}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "; app\n[mysql]\nport=3307 ; default\npassword=p$$w%%d\n\n[redis]\npassword=${REDIS_PASSWORD}\n"
+	if string(b) != want {
+		t.Errorf("got\n%s\nwant\n%s", b, want)
+	}
+	info, err := os.Stat(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("got mode %v, want 0600", info.Mode().Perm())
+	}
+	if err := runSet([]string{fileName, "mysql.port"}); err == nil || !strings.Contains(err.Error(), "usage") {
+		t.Errorf("got %v, want the usage", err)
+	}
+}
+
+// TestRunDiff 有不同时返回错误, 相同时返回 nil
+func TestRunDiff(t *testing.T) {
+	a := writeConfig(t, "a.ini", "[mysql]\nport=3306\npassword=a\n", 0644)
+	b := writeConfig(t, "b.ini", "[mysql]\nport=3307\npassword=b\nuser=root\n", 0644)
+	if err := runDiff([]string{a, a}); err != nil {
+		t.Errorf("same file: %v", err)
+	}
+	if err := runDiff([]string{a, b}); err == nil || err.Error() != "3 key(s) differ" {
+		t.Errorf("got %v, want 3 key(s) differ", err)
+	}
+}
+
+// TestRunValidate 给出 schema 时按 Config 严格检查
+func TestRunValidate(t *testing.T) {
+	good := writeConfig(t, "good.ini", "[mysql]\naddress=db\nusername=root\n", 0644)
+	bad := writeConfig(t, "bad.ini", "[mysql]\naddress=db\nusername=root\ntimeout=5\n", 0644)
+	if err := runValidate([]string{"-schema", "config", good}); err != nil {
+		t.Errorf("good.ini: %v", err)
+	}
+	if err := runValidate([]string{bad}); err != nil {
+		t.Errorf("bad.ini without a schema: %v", err)
+	}
+	if err := runValidate([]string{"-schema", "config", good, bad}); err == nil || err.Error() != "1 file(s) failed" {
+		t.Errorf("got %v, want 1 file(s) failed", err)
+	}
+}
@@ -0,0 +1,37 @@
+package iniparser
+
+import "time"
+
+// initool 示例使用的配置, 对应 cmd/initool/config.ini, MySQLConfig 也用于 mysqlDemo
+
+// MySQL config 配置结构体
+type MySQLConfig struct {
+	Address string `ini:"address" required:"true" validate:"host" doc:"MySQL server host name or IP address"`
+	Port int `ini:"port" default:"3306" validate:"min=1,max=65535" doc:"MySQL server port"`
+	Username string `ini:"username" required:"true" doc:"user to connect as"`
+	Password string `ini:"password" secret:"true" doc:"password of the user"`
+	Database string `ini:"database" doc:"default database, empty for none"`
+	Charset string `ini:"charset" default:"utf8mb4" validate:"regex=^[A-Za-z0-9_]+(,[A-Za-z0-9_]+)*$" doc:"connection character set, several can be separated by commas"`
+	Timeout time.Duration `ini:"timeout" validate:"min=0s" doc:"timeout for establishing a connection, 0 uses the OS default"`
+	ReadTimeout time.Duration `ini:"read_timeout" validate:"min=0s" doc:"I/O read timeout, 0 for none"`
+	WriteTimeout time.Duration `ini:"write_timeout" validate:"min=0s" doc:"I/O write timeout, 0 for none"`
+	TLS string `ini:"tls" default:"false" validate:"oneof=true false skip-verify preferred" doc:"TLS mode: true, false, skip-verify or preferred"`
+	MaxOpenConns int `ini:"max_open_conns" validate:"min=0" doc:"maximum number of open connections, 0 for unlimited"`
+	MaxIdleConns int `ini:"max_idle_conns" default:"2" validate:"min=0" doc:"maximum number of idle connections in the pool"`
+	ConnMaxLifetime time.Duration `ini:"conn_max_lifetime" validate:"min=0s" doc:"maximum time a connection may be reused, 0 for forever"`
+}
+
+// Redis config 配置结构体
+type RedisConfig struct {
+	Host string `ini:"host" default:"127.0.0.1" validate:"host" doc:"Redis server host name or IP address"`
+	Port int `ini:"port" default:"6379" validate:"min=1,max=65535" doc:"Redis server port"`
+	Password string `ini:"password" secret:"true" doc:"password for AUTH, empty when not required"`
+	Database string `ini:"database" doc:"database number to SELECT"`
+	Test bool `ini:"test" doc:"use the test instance"`
+}
+
+// Config 配置结构体
+type Config struct {
+	MySQLConfig `ini:"mysql" doc:"MySQL connection"`
+	RedisConfig `ini:"redis" doc:"Redis connection"`
+}
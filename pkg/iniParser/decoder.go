@@ -0,0 +1,381 @@
+package iniparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 除了 ini, 配置还可以是 JSON 或 TOML 的子集, 按扩展名选择, 或者用 Options.Format 指定
+//   err := LoadIni("config.json", &cfg)
+//   err := Unmarshal(body, &cfg, Options{Format: "toml"})
+// 每种格式先解析成 Document, 之后和 ini 完全一样: ini tag, 默认值, 必填, 校验, 变量展开和 include 都相同
+//   JSON  顶层是对象, 对象类型的值是节, 嵌套的对象是 [mysql.replica] 这样的子节,
+//         数组是重复的键(对应切片字段), null 忽略
+//   TOML  [table] 和 [a.b], key = value, 值是带引号的字符串, 整数, 浮点数, 布尔值, 日期时间
+//         或单行的数组, 不支持多行字符串, 多行数组, 内联表, [[数组表]] 和 a.b = 1 这样的键
+// JSON 和 TOML 解析出的 Document 只用于解析, 不能写回
+
+// Decoder 把一种格式的内容解析成 Document
+type Decoder interface {
+	Decode(b []byte) (*Document, error)
+}
+
+// INIDecoder ini 格式, 即 ParseDocument
+type INIDecoder struct{}
+
+// JSONDecoder JSON 格式
+type JSONDecoder struct{}
+
+// TOMLDecoder TOML 的子集
+type TOMLDecoder struct{}
+
+// decoders 格式名对应的 Decoder, extensions 扩展名对应的格式名
+var (
+	decoders = map[string]Decoder{
+		"ini": INIDecoder{},
+		"json": JSONDecoder{},
+		"toml": TOMLDecoder{},
+	}
+	extensions = map[string]string{
+		".ini": "ini",
+		".cfg": "ini",
+		".conf": "ini",
+		".json": "json",
+		".toml": "toml",
+	}
+)
+
+// RegisterDecoder 注册一种格式, exts 是使用这种格式的扩展名(带点号), 在 init 中调用
+func RegisterDecoder(format string, d Decoder, exts ...string) {
+	decoders[format] = d
+	for _, ext := range exts {
+		extensions[strings.ToLower(ext)] = format
+	}
+}
+
+// FormatOf 按扩展名返回文件的格式名, 不认识的扩展名按 ini 处理
+func FormatOf(fileName string) string {
+	if format, ok := extensions[strings.ToLower(filepath.Ext(fileName))]; ok {
+		return format
+	}
+	return "ini"
+}
+
+// decoderFor 返回 format 的 Decoder, format 为空时见 FormatOf
+func decoderFor(format, fileName string) (Decoder, error) {
+	if len(format) == 0 {
+		format = FormatOf(fileName)
+	}
+	d, ok := decoders[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown config format %s", format)
+	}
+	return d, nil
+}
+
+// parseBytes 按 format 解析没有所在目录的内容, 这时不支持 include
+func parseBytes(b []byte, format string) (*Document, error) {
+	d, err := decoderFor(format, "")
+	if err != nil {
+		return nil, err
+	}
+	doc, err := d.Decode(b)
+	if err != nil {
+		return nil, err
+	}
+	global := doc.Sections()[0]
+	if k := global.Key(includeKey); k != nil {
+		return nil, keyError(global, k, errors.New("include is not supported without a file system"))
+	}
+	return doc, nil
+}
+
+// Decode 实现 Decoder
+func (INIDecoder) Decode(b []byte) (*Document, error) {
+	return ParseDocument(b)
+}
+
+// Decode 实现 Decoder, 用 json.Decoder 逐个读取, 保持键在文件中的顺序
+func (JSONDecoder) Decode(b []byte) (*Document, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	doc := &Document{}
+	global := &Section{}
+	doc.sections = append(doc.sections, global)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, jsonError(b, dec, err)
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return nil, offsetError(b, 0, fmt.Errorf("%w: top level should be an object", ErrSyntax))
+	}
+	if err = decodeJSONObject(dec, b, doc, global, nil); err != nil {
+		return nil, err
+	}
+	if _, err = dec.Token(); err != io.EOF {
+		return nil, offsetError(b, int(dec.InputOffset()), fmt.Errorf("%w: unexpected data after top-level object", ErrSyntax))
+	}
+	return doc, nil
+}
+
+// decodeJSONObject 读取对象剩下的内容, 普通的值加到 sec 中, 对象类型的值作为子节加到 doc 中
+func decodeJSONObject(dec *json.Decoder, b []byte, doc *Document, sec *Section, path []string) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return jsonError(b, dec, err)
+		}
+		name := tok.(string)
+		line := lineAt(b, int(dec.InputOffset()))
+		if tok, err = dec.Token(); err != nil {
+			return jsonError(b, dec, err)
+		}
+		switch v := tok.(type) {
+		case json.Delim:
+			if v == '{' {
+				subPath := append(path[:len(path):len(path)], name)
+				sub := &Section{Name: strings.Join(subPath, "."), Line: line}
+				doc.sections = append(doc.sections, sub)
+				if err = decodeJSONObject(dec, b, doc, sub, subPath); err != nil {
+					return err
+				}
+				continue
+			}
+			// 数组中的每个值是一个重复的键
+			for dec.More() {
+				if tok, err = dec.Token(); err != nil {
+					return jsonError(b, dec, err)
+				}
+				value, ok := jsonScalar(tok)
+				if !ok {
+					return offsetError(b, int(dec.InputOffset()), fmt.Errorf("%s: only arrays of strings, numbers and booleans are supported", name))
+				}
+				sec.keys = append(sec.keys, &Key{Name: name, Value: value, Line: line})
+			}
+			if _, err = dec.Token(); err != nil {
+				return jsonError(b, dec, err)
+			}
+		case nil:
+		default:
+			value, _ := jsonScalar(v)
+			sec.keys = append(sec.keys, &Key{Name: name, Value: value, Line: line})
+		}
+	}
+	// 对象结尾的 }
+	if _, err := dec.Token(); err != nil {
+		return jsonError(b, dec, err)
+	}
+	return nil
+}
+
+// jsonScalar 把 JSON 的字符串, 数字和布尔值转成 ini 中的写法
+func jsonScalar(tok json.Token) (string, bool) {
+	switch v := tok.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+// jsonError 把 encoding/json 的错误转成带行号的 ParseError
+func jsonError(b []byte, dec *json.Decoder, err error) error {
+	offset := int(dec.InputOffset())
+	var se *json.SyntaxError
+	if errors.As(err, &se) {
+		offset = int(se.Offset)
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return offsetError(b, offset, fmt.Errorf("%w: %v", ErrSyntax, err))
+}
+
+// lineAt 返回 b 中第 offset 个字节所在的行号
+func lineAt(b []byte, offset int) int {
+	if offset > len(b) {
+		offset = len(b)
+	}
+	return bytes.Count(b[:offset], []byte("\n")) + 1
+}
+
+// offsetError 生成 b 中第 offset 个字节处的 ParseError
+func offsetError(b []byte, offset int, err error) *ParseError {
+	if offset > len(b) {
+		offset = len(b)
+	}
+	start := bytes.LastIndexByte(b[:offset], '\n') + 1
+	end := bytes.IndexByte(b[offset:], '\n')
+	if end == -1 {
+		end = len(b)
+	} else {
+		end += offset
+	}
+	return &ParseError{
+		Line: lineAt(b, offset),
+		Column: utf8.RuneCount(b[start:offset]) + 1,
+		Raw: strings.TrimRight(string(b[start:end]), "\r"),
+		Err: err,
+	}
+}
+
+// Decode 实现 Decoder, 先按 ini 的规则分出节和键, 再按 TOML 的规则解释值
+func (TOMLDecoder) Decode(b []byte) (*Document, error) {
+	// [[数组表]] 按 ini 的规则是语法错误, 先找出来给出明确的原因
+	if err := tomlArrayTable(b); err != nil {
+		return nil, err
+	}
+	doc, err := ParseDocument(b)
+	if err != nil {
+		return nil, err
+	}
+	for _, sec := range doc.sections {
+		if len(sec.Parent) > 0 || strings.HasPrefix(sec.Name, "[") {
+			return nil, sectionError(sec, fmt.Errorf("%w: unsupported table header in TOML", ErrSyntax))
+		}
+		var keys []*Key
+		for _, k := range sec.keys {
+			name := k.Name
+			if len(name) > 0 && (name[0] == '"' || name[0] == '\'') {
+				unquoted, end, err := scanQuoted(name, 0)
+				if err != nil || end != len(name) {
+					return nil, keyNameError(sec, k, fmt.Errorf("%w: incorrect quoted key", ErrSyntax))
+				}
+				name = unquoted
+			} else if strings.Contains(name, ".") {
+				return nil, keyNameError(sec, k, fmt.Errorf("%w: dotted keys are not supported", ErrSyntax))
+			}
+			dl := k.line
+			values, err := tomlValue(dl.raw[dl.valStart:dl.valEnd], k.Value)
+			if err != nil {
+				return nil, keyError(sec, k, err)
+			}
+			for _, value := range values {
+				copied := *k
+				copied.Name, copied.Value = name, value
+				keys = append(keys, &copied)
+			}
+		}
+		sec.keys = keys
+	}
+	return doc, nil
+}
+
+// tomlArrayTable 找到第一个 [[数组表]] 的标题, 返回对应的错误, 没有时返回 nil
+func tomlArrayTable(b []byte) error {
+	offset := 0
+	for _, line := range bytes.SplitAfter(b, []byte("\n")) {
+		trimmed := bytes.TrimLeft(line, " \t\ufeff")
+		if bytes.HasPrefix(trimmed, []byte("[[")) {
+			return offsetError(b, offset+len(line)-len(trimmed), fmt.Errorf("%w: unsupported table header in TOML - arrays of tables", ErrSyntax))
+		}
+		offset += len(line)
+	}
+	return nil
+}
+
+// tomlDateTime TOML 的日期时间, 整个值都要匹配, 如 1979-05-27, 07:32:00.5, 1979-05-27T07:32:00-07:00
+var tomlDateTime = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}([Tt ]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})?)?|\d{2}:\d{2}:\d{2}(\.\d+)?)$`)
+
+// tomlValue 解释 TOML 的值, token 是文件中的原文, lexed 是按 ini 规则取出的值, 数组返回多个值
+func tomlValue(token, lexed string) ([]string, error) {
+	switch {
+	case len(token) == 0:
+		return nil, fmt.Errorf("%w: missing value", ErrSyntax)
+	case token[0] == '"' || token[0] == '\'':
+		return []string{lexed}, nil
+	case token[0] != '[':
+		value, err := tomlBare(token)
+		if err != nil {
+			return nil, err
+		}
+		return []string{value}, nil
+	}
+	if !strings.HasSuffix(token, "]") {
+		return nil, fmt.Errorf("%w: unterminated array, arrays must be on one line", ErrSyntax)
+	}
+	var values []string
+	inner := token[1 : len(token)-1]
+	for i := 0; i < len(inner); {
+		start, _ := trimRange(inner, i, len(inner))
+		if start == len(inner) {
+			break
+		}
+		var value string
+		end := start
+		switch inner[start] {
+		case '"', '\'':
+			var err error
+			if value, end, err = scanQuoted(inner, start); err != nil {
+				return nil, err
+			}
+		case '[', '{':
+			return nil, fmt.Errorf("%w: nested arrays and inline tables are not supported", ErrSyntax)
+		default:
+			for end < len(inner) && inner[end] != ',' {
+				end++
+			}
+			_, trimmed := trimRange(inner, start, end)
+			bare, err := tomlBare(inner[start:trimmed])
+			if err != nil {
+				return nil, err
+			}
+			value = bare
+		}
+		values = append(values, value)
+		// 值之后是逗号或数组结尾, 最后一个值后面可以有逗号
+		end, _ = trimRange(inner, end, len(inner))
+		if end < len(inner) && inner[end] != ',' {
+			return nil, fmt.Errorf("%w: expected , in array", ErrSyntax)
+		}
+		i = end + 1
+	}
+	return values, nil
+}
+
+// tomlBare 检查不带引号的值, 只能是布尔值, 数字或日期时间, 整数统一成十进制
+func tomlBare(s string) (string, error) {
+	switch s {
+	case "true", "false", "inf", "+inf", "-inf", "nan", "+nan", "-nan":
+		return s, nil
+	}
+	// 日期时间原样交给 time.Time 字段, 用 layout tag 指定格式
+	if tomlDateTime.MatchString(s) {
+		return s, nil
+	}
+	digits := strings.TrimLeft(s, "+-")
+	if len(digits) > 1 && digits[0] == '0' {
+		switch digits[1] {
+		case 'x', 'o', 'b', 'X', 'O', 'B':
+			// 0x, 0o, 0b 开头的整数不能带符号, 前缀必须是小写
+			if n, err := strconv.ParseInt(digits, 0, 64); err == nil && digits == s && digits[1] >= 'a' {
+				return strconv.FormatInt(n, 10), nil
+			}
+			return "", fmt.Errorf("%w: incorrect integer - %s", ErrSyntax, s)
+		case '.', 'e', 'E':
+		default:
+			// TOML 不允许前导零, 0755 不是八进制
+			return "", fmt.Errorf("%w: leading zeros are not allowed - %s", ErrSyntax, s)
+		}
+	}
+	// 没有前导零时 ParseInt 按十进制解析, 并检查下划线的位置
+	if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return strconv.FormatInt(n, 10), nil
+	}
+	plain := strings.ReplaceAll(s, "_", "")
+	if _, err := strconv.ParseFloat(plain, 64); err == nil {
+		return plain, nil
+	}
+	return "", fmt.Errorf("%w: string values must be quoted - %s", ErrSyntax, s)
+}
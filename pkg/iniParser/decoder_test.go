@@ -0,0 +1,149 @@
+package iniparser
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type decoderConfig struct {
+	Name string `ini:"name"`
+	MySQL struct {
+		Address string `ini:"address"`
+		Port int `ini:"port" default:"3306"`
+		Hosts []string `ini:"hosts"`
+		Debug bool `ini:"debug"`
+		Ratio float64 `ini:"ratio"`
+		Replica struct {
+			Port int `ini:"port"`
+		} `ini:"replica"`
+	} `ini:"mysql"`
+}
+
+// TestDecoders 同样的配置写成 ini, JSON 和 TOML, 解析结果一样
+func TestDecoders(t *testing.T) {
+	files := map[string]string{
+		"config.ini": "name=app\n[mysql]\naddress=db\nhosts=a\nhosts=b,c\ndebug=true\nratio=0.5\n[mysql.replica]\nport=3307\n",
+		"config.json": `{"name": "app", "mysql": {"address": "db", "hosts": ["a", "b,c"], "debug": true, "ratio": 0.5, "port": null, "replica": {"port": 3307}}}`,
+		"config.toml": "name = \"app\"\n[mysql]\naddress = 'db'\nhosts = [\"a\", \"b,c\",]\ndebug = true\nratio = 5e-1 # half\n[mysql.replica]\nport = 3_307\n",
+	}
+	dir := writeFiles(t, files)
+	var want decoderConfig
+	want.Name = "app"
+	want.MySQL.Address, want.MySQL.Port, want.MySQL.Hosts = "db", 3306, []string{"a", "b,c"}
+	want.MySQL.Debug, want.MySQL.Ratio, want.MySQL.Replica.Port = true, 0.5, 3307
+	for name, content := range files {
+		var cfg decoderConfig
+		if err := LoadIni(filepath.Join(dir, name), &cfg, Options{Strict: true}); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("%s: got %+v, want %+v", name, cfg, want)
+		}
+		// 没有文件名时用 Options.Format 指定格式
+		var fromBytes decoderConfig
+		if err := Unmarshal([]byte(content), &fromBytes, Options{Format: FormatOf(name)}); err != nil || !reflect.DeepEqual(fromBytes, want) {
+			t.Errorf("%s from bytes: got %+v, %v", name, fromBytes, err)
+		}
+	}
+	if err := Unmarshal([]byte("a=1"), &decoderConfig{}, Options{Format: "yaml"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+// TestDecoderErrors 不支持的写法和语法错误带有行号
+func TestDecoderErrors(t *testing.T) {
+	tests := []struct {
+		format string
+		content string
+		line int
+		want string
+	}{
+		{"toml", "name = \"app\"\n\n[[servers]]\nport = 1\n", 3, "unsupported table header in TOML"},
+		{"toml", "[a]\n  [[a.b]] # nested\n", 2, "unsupported table header in TOML"},
+		{"toml", "[mysql : base]\n", 1, "unsupported table header in TOML"},
+		{"toml", "[mysql]\nreplica.port = 1\n", 2, "dotted keys are not supported"},
+		{"toml", "[mysql]\naddress = db\n", 2, "string values must be quoted"},
+		{"toml", "[mysql]\nhosts = [\"a\",\n", 2, "unterminated array"},
+		{"toml", "[mysql]\nhosts = [[1], [2]]\n", 2, "nested arrays"},
+		{"toml", "[mysql]\naddress =\n", 2, "missing value"},
+		{"json", "{\"mysql\": {\n\"port\": [{}]}}", 2, "only arrays of strings"},
+		{"json", "{\"mysql\": {\n\"port\": 1,,}}", 2, "invalid character"},
+		{"json", "[1]", 1, ""},
+	}
+	for _, tt := range tests {
+		err := Unmarshal([]byte(tt.content), &decoderConfig{}, Options{Format: tt.format})
+		var pe *ParseError
+		if !errors.As(err, &pe) || pe.Line != tt.line || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s %q: got %v, want %q on line %d", tt.format, tt.content, err, tt.want, tt.line)
+		}
+	}
+}
+
+// TestTOMLDateTime 整个值是日期时间时原样交给 time.Time 字段, 只有开头像日期的值不算
+func TestTOMLDateTime(t *testing.T) {
+	type config struct {
+		Event struct {
+			At time.Time `ini:"at"`
+			Day time.Time `ini:"day" layout:"2006-01-02"`
+			Clock string `ini:"clock"`
+		} `ini:"event"`
+	}
+	var cfg config
+	content := "[event]\nat = 1979-05-27T07:32:00-07:00\nday = 1979-05-27\nclock = 07:32:00.999\n"
+	if err := Unmarshal([]byte(content), &cfg, Options{Format: "toml"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Event.At.Unix() != 296663520 || cfg.Event.Day.Day() != 27 || cfg.Event.Clock != "07:32:00.999" {
+		t.Errorf("got %+v", cfg.Event)
+	}
+	for _, value := range []string{"1979-05-27junk", "1979-05-27T07:32", "07:32:00Z", "2024-01-01 extra"} {
+		err := Unmarshal([]byte("[event]\nclock = "+value+"\n"), &cfg, Options{Format: "toml"})
+		if !errors.Is(err, ErrSyntax) {
+			t.Errorf("clock = %s: got %v, want a syntax error", value, err)
+		}
+	}
+}
+
+// TestTOMLIntegers 整数统一成十进制, 不允许前导零
+func TestTOMLIntegers(t *testing.T) {
+	type server struct {
+		Port int `ini:"port"`
+	}
+	type config struct {
+		Server server `ini:"server"`
+	}
+	tests := []struct {
+		value string
+		want int
+	}{
+		{"8080", 8080},
+		{"+0", 0},
+		{"1_000", 1000},
+		{"0x1F", 31},
+		{"0o755", 493},
+		{"0b101", 5},
+	}
+	for _, tt := range tests {
+		var cfg config
+		err := Unmarshal([]byte("[server]\nport = "+tt.value+"\n"), &cfg, Options{Format: "toml"})
+		if err != nil {
+			t.Errorf("port = %s: %v", tt.value, err)
+			continue
+		}
+		if cfg.Server.Port != tt.want {
+			t.Errorf("port = %s: got %d, want %d", tt.value, cfg.Server.Port, tt.want)
+		}
+	}
+	for _, value := range []string{"0755", "-0755", "00", "0_1", "01.5", "-0x1", "0X1F"} {
+		var cfg config
+		err := Unmarshal([]byte("[server]\nport = "+value+"\n"), &cfg, Options{Format: "toml"})
+		if !errors.Is(err, ErrSyntax) {
+			t.Errorf("port = %s: got %v (port %d), want a syntax error", value, err, cfg.Server.Port)
+		}
+	}
+}
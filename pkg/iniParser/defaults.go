@@ -0,0 +1,89 @@
+package iniparser
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// 通过 struct tag 声明默认值和必填字段
+//   Port int `ini:"port" default:"3306"`
+//   Password string `ini:"password" required:"true"`
+
+// applyDefaults 在解析之前把 default tag 的值赋给结构体字段, 嵌套结构体递归处理
+// nil 的结构体指针不处理, 等解析时分配了再设置默认值
+func applyDefaults(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldObj := v.Field(i)
+		if !fieldObj.CanSet() {
+			continue
+		}
+		if fieldObj.Kind() == reflect.Struct && !isValueType(fieldObj.Type()) {
+			if err := applyDefaults(fieldObj); err != nil {
+				return err
+			}
+			continue
+		}
+		value, ok := field.Tag.Lookup("default")
+		if !ok {
+			continue
+		}
+		if err := setValue(fieldObj, value, field.Tag); err != nil {
+			return fmt.Errorf("field %s: incorrect default value - \"%s\": %v", field.Name, value, err)
+		}
+	}
+	return nil
+}
+
+// sectionKey 返回节路径和键拼成的名字, 如 mysql.replica.port
+func sectionKey(path []string, key string) string {
+	return strings.Join(append(path[:len(path):len(path)], key), ".")
+}
+
+// checkRequired 解析之后检查 required tag 的字段, 返回一个列出所有缺失键的错误
+// lines 是解析时赋过值的键所在的位置, 键由 sectionKey 生成
+func checkRequired(v reflect.Value, lines map[string]position) error {
+	var missing []string
+	collectMissing(v, nil, lines, &missing)
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("missing required keys: %s", strings.Join(missing, ", "))
+}
+
+// collectMissing 递归收集缺失的必填键, 格式为 [section] key
+// 文件中没有出现的可选节(nil 的结构体指针)不检查
+func collectMissing(v reflect.Value, path []string, lines map[string]position, missing *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("ini")
+		if len(name) == 0 {
+			continue
+		}
+		fieldObj := v.Field(i)
+		if isSectionType(fieldObj.Type()) && fieldObj.Kind() == reflect.Ptr {
+			if fieldObj.IsNil() {
+				continue
+			}
+			fieldObj = fieldObj.Elem()
+		}
+		if isSectionType(fieldObj.Type()) && fieldObj.Kind() == reflect.Struct {
+			collectMissing(fieldObj, append(path[:len(path):len(path)], name), lines, missing)
+			continue
+		}
+		if field.Tag.Get("required") != "true" {
+			continue
+		}
+		if _, ok := lines[sectionKey(path, name)]; ok {
+			continue
+		}
+		if len(path) == 0 {
+			*missing = append(*missing, name)
+		} else {
+			*missing = append(*missing, fmt.Sprintf("[%s] %s", strings.Join(path, "."), name))
+		}
+	}
+}
@@ -0,0 +1,455 @@
+package iniparser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ini 文档模型: 保留注释, 空行, 键的顺序和原始行号
+// 修改过的键才会重新生成, 其余内容写回时和原文件逐字节相同
+
+// 行的类型
+const (
+	blankLine = iota
+	commentLine
+	sectionLine
+	keyLine
+)
+
+// docLine 文档中的一行, raw 是不含换行符的原始内容
+type docLine struct {
+	kind int
+	raw string
+	key *Key
+	// 原始值在 raw 中的起止位置, 只改值时保留等号两边的格式
+	valStart int
+	valEnd int
+	// 解析时的原始键和值, 用来判断这一行是否被修改过
+	origName string
+	origValue string
+}
+
+// Key 节中的一个键值对
+type Key struct {
+	Name string
+	Value string
+	// Line 原始行号, 新加的键为 0
+	Line int
+	// Comment 紧挨在键上方的注释行
+	Comment string
+	line *docLine
+	// literal 值不做变量展开, 来自环境变量和命令行参数的值是这样
+	literal bool
+}
+
+// Section 文档中的一个节, 名字为空的节保存第一个 [section] 之前的内容
+type Section struct {
+	Name string
+	// Parent 节标题中 : 之后的父节名, 如 [mysql-prod : mysql], 没有时为空
+	Parent string
+	// File 节所在的文件, 从字节解析时为空, 分层加载时用来区分来源
+	File string
+	// Line 节标题的原始行号, 新加的节和全局节为 0
+	Line int
+	// Comment 紧挨在节标题上方的注释行
+	Comment string
+	// head 节标题和它上方的注释, body 是标题之后到下一个节之前的所有行
+	head []*docLine
+	body []*docLine
+	keys []*Key
+	// inherited 解析时从父节复制出来的节, 不在原文档中
+	inherited bool
+}
+
+// Document 解析后的 ini 文档
+type Document struct {
+	sections []*Section
+	// crlf 原文件使用 \r\n 换行, 新生成的行沿用同样的换行
+	crlf bool
+	// eofNewline 原文件以换行结尾
+	eofNewline bool
+	// bom 原文件以 UTF-8 BOM 开头
+	bom bool
+}
+
+// NewDocument 创建一个空文档
+func NewDocument() *Document {
+	doc := &Document{eofNewline: true}
+	doc.sections = []*Section{{}}
+	return doc
+}
+
+// ParseDocument 解析 ini 内容, 返回保留格式的文档
+// 值的写法见 lexer.go, 开头的 UTF-8 BOM 会去掉, \r\n 和 \n 换行都可以
+func ParseDocument(b []byte) (*Document, error) {
+	doc := &Document{}
+	s := string(b)
+	if strings.HasPrefix(s, bom) {
+		doc.bom = true
+		s = s[len(bom):]
+	}
+	if strings.HasSuffix(s, "\n") {
+		doc.eofNewline = true
+		s = s[:len(s)-1]
+	}
+	lineSlice := strings.Split(s, "\n")
+	if len(lineSlice) > 0 && strings.HasSuffix(lineSlice[0], "\r") {
+		doc.crlf = true
+	}
+	current := &Section{}
+	doc.sections = append(doc.sections, current)
+	// pending 还没确定归属的空行和注释, 遇到键时归当前节, 遇到节标题时紧挨着的注释归新节
+	var pending []*docLine
+	for index := 0; index < len(lineSlice); index++ {
+		raw := lineSlice[index]
+		// 去掉每行首位的空格, 避免情况如" [redis]"
+		line := strings.TrimSpace(raw)
+		// 1. 空行和注释先暂存
+		if len(line) == 0 {
+			pending = append(pending, &docLine{kind: blankLine, raw: raw})
+			continue
+		}
+		if isComment(line[0]) {
+			pending = append(pending, &docLine{kind: commentLine, raw: raw})
+			continue
+		}
+		// 2. 如果 [ 开头就是节标题, ] 之后可以有行内注释
+		if strings.HasPrefix(line, "[") {
+			// 处理边界情况 "[", "[    ]", "[a] b" 和 "[a : ]"
+			end := sectionEnd(line)
+			if end == -1 ||
+				(end+1 < len(line) && !isComment(strings.TrimSpace(line[end+1:])[0])) {
+				return nil, lineError(current, index+1, raw, strings.Index(raw, "["), ErrSyntax)
+			}
+			// 最后一个空行之后的注释属于新节, 之前的留在上一个节
+			split := len(pending)
+			for split > 0 && pending[split-1].kind == commentLine {
+				split--
+			}
+			name, parent, ok := splitSectionName(strings.TrimSpace(line[1:end]))
+			if !ok {
+				return nil, lineError(current, index+1, raw, strings.Index(raw, "["), ErrSyntax)
+			}
+			current.body = append(current.body, pending[:split]...)
+			current = &Section{
+				Name: name,
+				Parent: parent,
+				Line: index + 1,
+				Comment: commentText(pending[split:]),
+			}
+			current.head = append(append([]*docLine{}, pending[split:]...), &docLine{kind: sectionLine, raw: raw})
+			doc.sections = append(doc.sections, current)
+			pending = nil
+			continue
+		}
+		// 3. 其余的行是 = 分隔的键值对
+		eq := strings.Index(raw, "=")
+		if eq == -1 || strings.HasPrefix(line, "=") {
+			return nil, lineError(current, index+1, raw, len(raw)-len(strings.TrimLeft(raw, " \t")), ErrSyntax)
+		}
+		lineNo := index + 1
+		tok, err := lexValue(raw, eq+1)
+		if err != nil {
+			return nil, lineError(current, lineNo, raw, tok.start, err)
+		}
+		dl := &docLine{kind: keyLine, raw: raw, valStart: tok.start, valEnd: tok.end}
+		value := tok.value
+		// 续行: 后面的行拼到这一行的 raw 中, 写回时仍是多行
+		for tok.cont && index+1 < len(lineSlice) {
+			index++
+			offset := len(dl.raw) + 1
+			dl.raw += "\n" + lineSlice[index]
+			if tok, err = lexValue(dl.raw, offset); err != nil {
+				return nil, lineError(current, index+1, lineSlice[index], tok.start-offset, err)
+			}
+			value += tok.value
+			dl.valEnd = tok.end
+		}
+		dl.origName = strings.TrimSpace(raw[:eq])
+		dl.origValue = value
+		// 紧挨在键上方的注释作为键的注释
+		split := len(pending)
+		for split > 0 && pending[split-1].kind == commentLine {
+			split--
+		}
+		dl.key = &Key{
+			Name: dl.origName,
+			Value: dl.origValue,
+			Line: lineNo,
+			Comment: commentText(pending[split:]),
+			line: dl,
+		}
+		current.body = append(current.body, pending...)
+		current.body = append(current.body, dl)
+		current.keys = append(current.keys, dl.key)
+		pending = nil
+	}
+	current.body = append(current.body, pending...)
+	return doc, nil
+}
+
+// position 键在文件中的位置, 用于报错
+type position struct {
+	file string
+	line int
+}
+
+func (p position) String() string {
+	switch {
+	case p.line == 0 && len(p.file) == 0:
+		return "<unset>"
+	case p.line == 0:
+		return p.file
+	case len(p.file) == 0:
+		return fmt.Sprintf("line %d", p.line)
+	}
+	return fmt.Sprintf("%s:%d", p.file, p.line)
+}
+
+// trimRange 返回 s[start:end] 去掉首尾空白后的起止位置
+func trimRange(s string, start, end int) (int, int) {
+	for start < end && isSpace(s[start]) {
+		start++
+	}
+	for end > start && isSpace(s[end-1]) {
+		end--
+	}
+	return start, end
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\v' || c == '\f'
+}
+
+// commentText 把注释行去掉 ; 或 # 前缀后拼起来
+func commentText(lines []*docLine) string {
+	var texts []string
+	for _, dl := range lines {
+		if dl.kind != commentLine {
+			continue
+		}
+		text := strings.TrimSpace(dl.raw)
+		texts = append(texts, strings.TrimSpace(text[1:]))
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Sections 按文件顺序返回所有节, 第一个是名字为空的全局节
+func (doc *Document) Sections() []*Section {
+	return doc.sections
+}
+
+// Section 返回第一个名为 name 的节, 不存在时返回 nil
+func (doc *Document) Section(name string) *Section {
+	for _, sec := range doc.sections {
+		if sec.Name == name {
+			return sec
+		}
+	}
+	return nil
+}
+
+// AddSection 在文档末尾添加一个节, 同名的节已存在时直接返回它
+func (doc *Document) AddSection(name string) *Section {
+	if sec := doc.Section(name); sec != nil {
+		return sec
+	}
+	sec := &Section{Name: name}
+	// 和前面的内容之间空一行, 前面已经以空行结尾时(比如删掉过节)不再重复添加
+	if last := doc.lastLine(); last != nil && last.kind != blankLine {
+		sec.head = append(sec.head, &docLine{kind: blankLine, raw: doc.lineEnd()})
+	}
+	sec.head = append(sec.head, &docLine{kind: sectionLine, raw: "[" + name + "]" + doc.lineEnd()})
+	doc.sections = append(doc.sections, sec)
+	return sec
+}
+
+// lastLine 返回文档的最后一行, 空文档返回 nil
+func (doc *Document) lastLine() *docLine {
+	for i := len(doc.sections) - 1; i >= 0; i-- {
+		sec := doc.sections[i]
+		if len(sec.body) > 0 {
+			return sec.body[len(sec.body)-1]
+		}
+		if len(sec.head) > 0 {
+			return sec.head[len(sec.head)-1]
+		}
+	}
+	return nil
+}
+
+// RemoveSection 删除所有名为 name 的节以及它们上方的注释, 返回是否删除了
+func (doc *Document) RemoveSection(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	removed := false
+	sections := doc.sections[:0]
+	for _, sec := range doc.sections {
+		if sec.Name == name {
+			removed = true
+			continue
+		}
+		sections = append(sections, sec)
+	}
+	doc.sections = sections
+	return removed
+}
+
+// lookup 返回 section 节中的 key, 同名的节有多个时和 LoadIni 一样以最后出现的为准
+func (doc *Document) lookup(section, key string) *Key {
+	for i := len(doc.sections) - 1; i >= 0; i-- {
+		if doc.sections[i].Name != section {
+			continue
+		}
+		if k := doc.sections[i].Key(key); k != nil {
+			return k
+		}
+	}
+	return nil
+}
+
+// Get 返回 section 节中 key 的值
+func (doc *Document) Get(section, key string) (string, bool) {
+	if k := doc.lookup(section, key); k != nil {
+		return k.Value, true
+	}
+	return "", false
+}
+
+// Set 设置 section 节中 key 的值, 节或键不存在时自动添加
+func (doc *Document) Set(section, key, value string) {
+	if k := doc.lookup(section, key); k != nil {
+		k.Value = value
+		return
+	}
+	sec := doc.Section(section)
+	if sec == nil {
+		sec = doc.AddSection(section)
+	}
+	sec.SetKey(key, value)
+}
+
+// lineEnd 新生成的行需要的行尾, crlf 文件要补上 \r
+func (doc *Document) lineEnd() string {
+	if doc.crlf {
+		return "\r"
+	}
+	return ""
+}
+
+// Keys 按文件顺序返回节中的所有键, 重复的键会出现多次
+func (sec *Section) Keys() []*Key {
+	return sec.keys
+}
+
+// Key 返回节中名为 name 的键, 有重复时返回最后一个, 不存在时返回 nil
+func (sec *Section) Key(name string) *Key {
+	for i := len(sec.keys) - 1; i >= 0; i-- {
+		if sec.keys[i].Name == name {
+			return sec.keys[i]
+		}
+	}
+	return nil
+}
+
+// SetKey 设置键的值, 键不存在时添加在节中最后一个键的后面
+func (sec *Section) SetKey(name, value string) *Key {
+	if k := sec.Key(name); k != nil {
+		k.Value = value
+		return k
+	}
+	dl := &docLine{kind: keyLine}
+	k := &Key{Name: name, Value: value, line: dl}
+	dl.key = k
+	// 插在最后一个键之后, 保证节末尾的空行和注释仍在后面
+	pos := 0
+	for i, l := range sec.body {
+		if l.kind == keyLine {
+			pos = i + 1
+		}
+	}
+	sec.body = append(sec.body, nil)
+	copy(sec.body[pos+1:], sec.body[pos:])
+	sec.body[pos] = dl
+	sec.keys = append(sec.keys, k)
+	return k
+}
+
+// RemoveKey 删除节中所有名为 name 的键, 返回是否删除了
+func (sec *Section) RemoveKey(name string) bool {
+	removed := false
+	keys := sec.keys[:0]
+	for _, k := range sec.keys {
+		if k.Name == name {
+			removed = true
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sec.keys = keys
+	if !removed {
+		return false
+	}
+	body := sec.body[:0]
+	for _, dl := range sec.body {
+		if dl.kind == keyLine && dl.key.Name == name {
+			continue
+		}
+		body = append(body, dl)
+	}
+	sec.body = body
+	return true
+}
+
+// text 返回这一行写回时的内容, 没改过的行原样返回
+func (dl *docLine) text(doc *Document) string {
+	if dl.kind != keyLine {
+		return dl.raw
+	}
+	k := dl.key
+	if len(dl.raw) > 0 && k.Name == dl.origName && k.Value == dl.origValue {
+		return dl.raw
+	}
+	// 只改了值, 保留键和等号两边原来的格式, 续行的值改过之后合并成一行
+	if len(dl.raw) > 0 && k.Name == dl.origName {
+		return dl.raw[:dl.valStart] + quoteValue(k.Value) + dl.raw[dl.valEnd:]
+	}
+	return k.Name + "=" + quoteValue(k.Value) + doc.lineEnd()
+}
+
+// WriteTo 把文档写入 w, 实现 io.WriterTo
+func (doc *Document) WriteTo(w io.Writer) (int64, error) {
+	var lines []string
+	for _, sec := range doc.sections {
+		for _, dl := range sec.head {
+			lines = append(lines, dl.text(doc))
+		}
+		for _, dl := range sec.body {
+			lines = append(lines, dl.text(doc))
+		}
+	}
+	s := strings.Join(lines, "\n")
+	if doc.eofNewline && len(lines) > 0 {
+		s += "\n"
+	}
+	if doc.bom {
+		s = bom + s
+	}
+	n, err := io.WriteString(w, s)
+	return int64(n), err
+}
+
+// Bytes 返回文档写回后的内容
+func (doc *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	doc.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+// String 实现 fmt.Stringer
+func (doc *Document) String() string {
+	return string(doc.Bytes())
+}
@@ -0,0 +1,116 @@
+package iniparser
+
+import (
+	"testing"
+)
+
+// TestDocumentRoundTrip 没有修改的文档写回时和原文件逐字节相同
+func TestDocumentRoundTrip(t *testing.T) {
+	cases := map[string]string{
+		"comments": "; mysql config\n[mysql]\n# address\naddress = 10.20.30.40\n\n\nport=3306\n\n; redis\n[redis]\nhost=127.0.0.1\n",
+		"crlf": "; mysql config\r\n[mysql]\r\naddress=10.20.30.40\r\n\r\n[redis]\r\nhost=127.0.0.1\r\n",
+		"no trailing newline": "[mysql]\naddress=10.20.30.40\nport=3306",
+		"global keys": "name=demo\n\n[mysql]\nport=3306\n",
+		"indented": "  [mysql]\n\taddress =  10.20.30.40  \n",
+		"empty": "",
+	}
+	for name, in := range cases {
+		doc, err := ParseDocument([]byte(in))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if got := doc.String(); got != in {
+			t.Errorf("%s: round trip changed the document\ngot  %q\nwant %q", name, got, in)
+		}
+	}
+}
+
+// TestDocumentSet 改值时保留等号两边的格式, 新键和新节追加在合适的位置
+func TestDocumentSet(t *testing.T) {
+	in := "[mysql]\n; address\naddress = 10.20.30.40 \nport=3306\n\n; trailing comment\n"
+	doc, err := ParseDocument([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Set("mysql", "address", "127.0.0.1")
+	doc.Set("mysql", "username", "root")
+	doc.Set("redis", "port", "6379")
+	want := "[mysql]\n; address\naddress = 127.0.0.1 \nport=3306\nusername=root\n\n; trailing comment\n\n[redis]\nport=6379\n"
+	if got := doc.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	if v, ok := doc.Get("mysql", "username"); !ok || v != "root" {
+		t.Errorf("Get(mysql, username) = %q, %v", v, ok)
+	}
+	if k := doc.Section("mysql").Key("address"); k.Comment != "address" || k.Line != 3 {
+		t.Errorf("address key = %+v", k)
+	}
+}
+
+// TestDocumentSetKeyCRLF crlf 文件中新生成的行也用 \r\n 换行
+func TestDocumentSetKeyCRLF(t *testing.T) {
+	doc, err := ParseDocument([]byte("[mysql]\r\nport=3306\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Section("mysql").SetKey("address", "127.0.0.1")
+	doc.AddSection("redis").SetKey("port", "6379")
+	want := "[mysql]\r\nport=3306\r\naddress=127.0.0.1\r\n\r\n[redis]\r\nport=6379\r\n"
+	if got := doc.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+// TestDocumentSections 添加和删除节
+func TestDocumentSections(t *testing.T) {
+	in := "[mysql]\nport=3306\n\n; redis config\n[redis]\nport=6379\n"
+	doc, err := ParseDocument([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sec := doc.AddSection("mysql"); sec != doc.Section("mysql") {
+		t.Error("AddSection should return the existing section")
+	}
+	if doc.RemoveSection("") {
+		t.Error("the global section should not be removable")
+	}
+	if !doc.RemoveSection("redis") {
+		t.Fatal("RemoveSection(redis) = false")
+	}
+	if doc.RemoveSection("redis") {
+		t.Error("RemoveSection should report false for a missing section")
+	}
+	if got, want := doc.String(), "[mysql]\nport=3306\n\n"; got != want {
+		t.Errorf("after RemoveSection got %q, want %q", got, want)
+	}
+	// 删掉节后再添加, 不应该出现两个空行
+	doc.AddSection("cache").SetKey("size", "10")
+	if got, want := doc.String(), "[mysql]\nport=3306\n\n[cache]\nsize=10\n"; got != want {
+		t.Errorf("after AddSection got %q, want %q", got, want)
+	}
+	// 空文档添加节时前面没有空行
+	doc = NewDocument()
+	doc.Set("mysql", "port", "3306")
+	if got, want := doc.String(), "[mysql]\nport=3306\n"; got != want {
+		t.Errorf("new document got %q, want %q", got, want)
+	}
+}
+
+// TestSectionRemoveKey 删除重复的键时所有同名的行都被删掉
+func TestSectionRemoveKey(t *testing.T) {
+	doc, err := ParseDocument([]byte("[mysql]\nport=1\naddress=a\nport=2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sec := doc.Section("mysql")
+	if k := sec.Key("port"); k.Value != "2" {
+		t.Errorf("Key(port) = %q, want the last value", k.Value)
+	}
+	if !sec.RemoveKey("port") || sec.RemoveKey("port") {
+		t.Error("RemoveKey should remove once")
+	}
+	if got, want := doc.String(), "[mysql]\naddress=a\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
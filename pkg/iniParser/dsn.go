@@ -0,0 +1,110 @@
+package iniparser
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 用配置生成连接串, 服务中不用再手写
+//   db, err := sql.Open("mysql", dsn)  // dsn, err := cfg.MySQLConfig.DSN()
+//   cfg.MySQLConfig.SetPool(db)
+//   redis.NewClient(&redis.Options{Addr: cfg.RedisConfig.Addr(), ...})
+
+// charsetRegexp 逗号分隔的字符集名, 驱动按逗号拆开后原样使用, 不做 URL 解码
+var charsetRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+(,[A-Za-z0-9_]+)*$`)
+
+// tlsModes DSN 中 tls 参数可以使用的值
+var tlsModes = map[string]bool{"true": true, "false": true, "skip-verify": true, "preferred": true}
+
+// DSN 生成 go-sql-driver/mysql 的连接串 username:password@tcp(address:port)/database?param=value
+// 驱动按最后一个 @ 和 / 拆分, 密码原样写入, 库名做 URL 转义, IPv6 地址加上方括号
+// 驱动只对部分参数做 URL 解码, charset 和时长都按原样解析, 所以参数不转义, 写入前检查它们的值
+func (c MySQLConfig) DSN() (string, error) {
+	if len(c.Address) == 0 {
+		return "", errors.New("mysql address is empty")
+	}
+	// 驱动按第一个冒号拆分用户名和密码, 用户名中不能有冒号
+	if strings.Contains(c.Username, ":") {
+		return "", errors.New("mysql username should not contain ':'")
+	}
+	if len(c.Charset) > 0 && !charsetRegexp.MatchString(c.Charset) {
+		return "", fmt.Errorf("incorrect mysql charset - %s", c.Charset)
+	}
+	if len(c.TLS) > 0 && !tlsModes[c.TLS] {
+		return "", fmt.Errorf("unknown mysql tls mode - %s", c.TLS)
+	}
+	var b strings.Builder
+	b.WriteString(c.Username)
+	if len(c.Password) > 0 {
+		b.WriteString(":")
+		b.WriteString(c.Password)
+	}
+	b.WriteString("@tcp(")
+	b.WriteString(net.JoinHostPort(c.Address, strconv.Itoa(c.Port)))
+	b.WriteString(")/")
+	b.WriteString(url.PathEscape(c.Database))
+	params := make(map[string]string)
+	if len(c.Charset) > 0 {
+		params["charset"] = c.Charset
+	}
+	if len(c.TLS) > 0 && c.TLS != "false" {
+		params["tls"] = c.TLS
+	}
+	if c.Timeout > 0 {
+		params["timeout"] = c.Timeout.String()
+	}
+	if c.ReadTimeout > 0 {
+		params["readTimeout"] = c.ReadTimeout.String()
+	}
+	if c.WriteTimeout > 0 {
+		params["writeTimeout"] = c.WriteTimeout.String()
+	}
+	// 参数按名字排序, 同样的配置生成同样的连接串
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if i == 0 {
+			b.WriteString("?")
+		} else {
+			b.WriteString("&")
+		}
+		b.WriteString(name)
+		b.WriteString("=")
+		b.WriteString(params[name])
+	}
+	return b.String(), nil
+}
+
+// SetPool 按配置设置连接池的大小和连接的最长使用时间
+func (c MySQLConfig) SetPool(db *sql.DB) {
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+}
+
+// Addr 返回 host:port, 用于 go-redis 等客户端的 Addr 选项
+func (c RedisConfig) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// URL 返回 redis://:password@host:port/database, 密码和库号做 URL 转义
+func (c RedisConfig) URL() string {
+	u := url.URL{Scheme: "redis", Host: c.Addr()}
+	if len(c.Password) > 0 {
+		u.User = url.UserPassword("", c.Password)
+	}
+	if len(c.Database) > 0 {
+		u.Path = "/" + c.Database
+	}
+	return u.String()
+}
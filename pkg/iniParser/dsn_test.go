@@ -0,0 +1,95 @@
+package iniparser
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMySQLDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg MySQLConfig
+		want string
+	}{
+		{
+			"minimal",
+			MySQLConfig{Address: "127.0.0.1", Port: 3306, Username: "root"},
+			"root@tcp(127.0.0.1:3306)/",
+		},
+		{
+			"escaping",
+			MySQLConfig{Address: "::1", Port: 3306, Username: "root", Password: "p@ss/w:rd?&", Database: "go db/x"},
+			"root:p@ss/w:rd?&@tcp([::1]:3306)/go%20db%2Fx",
+		},
+		{
+			// 驱动按逗号拆分 charset, 不做 URL 解码
+			"params",
+			MySQLConfig{Address: "db", Port: 3307, Username: "u", Charset: "utf8mb4,utf8", TLS: "skip-verify",
+				Timeout: 5 * time.Second, ReadTimeout: 500 * time.Microsecond},
+			"u@tcp(db:3307)/?charset=utf8mb4,utf8&readTimeout=500µs&timeout=5s&tls=skip-verify",
+		},
+		{
+			"tls false",
+			MySQLConfig{Address: "db", Port: 3306, Username: "u", TLS: "false"},
+			"u@tcp(db:3306)/",
+		},
+	}
+	for _, tt := range tests {
+		got, err := tt.cfg.DSN()
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+	for _, cfg := range []MySQLConfig{
+		{Port: 3306, Username: "u"},
+		{Address: "db", Port: 3306, Username: "a:b"},
+		{Address: "db", Port: 3306, Username: "u", Charset: "utf8&x=1"},
+		{Address: "db", Port: 3306, Username: "u", TLS: "maybe"},
+	} {
+		if dsn, err := cfg.DSN(); err == nil {
+			t.Errorf("%+v: got %q, want an error", cfg, dsn)
+		}
+	}
+}
+
+func TestMySQLCharsetValidation(t *testing.T) {
+	var cfg Config
+	err := Unmarshal([]byte("[mysql]\naddress=db\nusername=u\ncharset=utf8mb4,utf8\n[redis]\nhost=db\n"), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Unmarshal([]byte("[mysql]\naddress=db\nusername=u\ncharset=utf8 mb4\n[redis]\nhost=db\n"), &cfg)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Errorf("got %v, want a validation error", err)
+	}
+}
+
+func TestHostRule(t *testing.T) {
+	for _, address := range []string{"db", "db.example.com", "10.0.0.1", "::1", "fe80::1"} {
+		var cfg Config
+		err := Unmarshal([]byte("[mysql]\naddress="+address+"\nusername=u\n[redis]\nhost="+address+"\n"), &cfg)
+		if err != nil {
+			t.Errorf("%s: %v", address, err)
+		}
+	}
+	for _, address := range []string{"[::1]", "db_1", "-db"} {
+		var cfg Config
+		err := Unmarshal([]byte("[mysql]\naddress="+address+"\nusername=u\n[redis]\nhost="+address+"\n"), &cfg)
+		var errs ValidationErrors
+		if !errors.As(err, &errs) || len(errs) != 2 {
+			t.Errorf("%s: got %v, want errors for mysql.address and redis.host", address, err)
+		}
+	}
+}
+
+func TestRedisAddr(t *testing.T) {
+	cfg := RedisConfig{Host: "::1", Port: 6379, Password: "a b@c", Database: "2"}
+	if got := cfg.Addr(); got != "[::1]:6379" {
+		t.Errorf("Addr() = %q", got)
+	}
+	if got := cfg.URL(); got != "redis://:a%20b%40c@[::1]:6379/2" {
+		t.Errorf("URL() = %q", got)
+	}
+}
@@ -0,0 +1,229 @@
+package iniparser
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ini 编码器, LoadIni 的逆操作: 把配置结构体写回 ini 格式
+// 值中的 $ 和 % 写成 $$ 和 %%, 读回来时不会被当成变量展开
+
+// MarshalIni 把配置结构体编码成 ini 格式的字节
+func MarshalIni(data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := SaveIni(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SaveIni 把配置结构体以 ini 格式写入 w
+// data 可以是结构体或结构体指针, 每个带 ini tag 的嵌套结构体或 map 字段对应一个 [section]
+func SaveIni(w io.Writer, data interface{}) (err error) {
+	// 0. 参数的校验
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return errors.New("input should not be a nil pointer")
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return errors.New("input should be a struct")
+	}
+	// 1. 顶层的键写在第一个 section 之前
+	var global bytes.Buffer
+	if err = writeFields(&global, v); err != nil {
+		return
+	}
+	first := global.Len() == 0
+	if _, err = global.WriteTo(w); err != nil {
+		return
+	}
+	return writeSections(w, "", v, &first)
+}
+
+// writeSections 把结构体中每个带 ini tag 的嵌套结构体或 map 写成一个 section
+// prefix 是上一级的节名, 嵌套的结构体写成 [mysql.replica] 这样的节, nil 的结构体指针跳过
+func writeSections(w io.Writer, prefix string, v reflect.Value, first *bool) (err error) {
+	t := v.Type()
+	// 2. 遍历结构体的字段
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("ini")
+		fieldObj := v.Field(i)
+		if len(name) == 0 || !isSectionType(fieldObj.Type()) {
+			continue
+		}
+		if fieldObj.Kind() == reflect.Ptr {
+			if fieldObj.IsNil() {
+				continue
+			}
+			fieldObj = fieldObj.Elem()
+		}
+		kind := fieldObj.Kind()
+		sectionName := name
+		if len(prefix) > 0 {
+			sectionName = prefix + "." + name
+		}
+		// section 之间空一行
+		if !*first {
+			if _, err = fmt.Fprintln(w); err != nil {
+				return
+			}
+		}
+		*first = false
+		if _, err = fmt.Fprintf(w, "[%s]\n", sectionName); err != nil {
+			return
+		}
+		// 3. 遍历 map 的键或嵌套结构体的字段, 写成 key=value, 再写更深一级的节
+		if kind == reflect.Map {
+			err = writeMap(w, fieldObj)
+		} else if err = writeFields(w, fieldObj); err == nil {
+			err = writeSections(w, sectionName, fieldObj, first)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// writeFields 把嵌套结构体带 ini tag 的字段写成 key=value
+func writeFields(w io.Writer, sValue reflect.Value) (err error) {
+	sType := sValue.Type()
+	for j := 0; j < sType.NumField(); j++ {
+		field := sType.Field(j)
+		key := field.Tag.Get("ini")
+		if len(key) == 0 {
+			continue
+		}
+		fieldObj := sValue.Field(j)
+		if fieldObj.Kind() == reflect.Slice && !isValueType(fieldObj.Type()) {
+			err = writeSlice(w, key, fieldObj, field.Tag)
+			if err != nil {
+				return
+			}
+			continue
+		}
+		value, ok := formatValue(fieldObj, field.Tag)
+		if !ok {
+			// 不支持的类型, 和 LoadIni 一样跳过
+			continue
+		}
+		if _, err = fmt.Fprintf(w, "%s=%s\n", key, quoteValue(EscapeValue(value))); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// writeSlice 把切片写成逗号分隔的一行
+// 有元素本身含逗号时改写成重复的键, 每行一个元素, 读回来时不再按逗号拆分
+func writeSlice(w io.Writer, key string, fieldObj reflect.Value, tag reflect.StructTag) (err error) {
+	// 空切片不写, 读回来仍是 nil
+	if fieldObj.Len() == 0 {
+		return
+	}
+	items := make([]string, 0, fieldObj.Len())
+	for i := 0; i < fieldObj.Len(); i++ {
+		item, ok := formatValue(fieldObj.Index(i), tag)
+		if !ok {
+			return
+		}
+		items = append(items, item)
+	}
+	if !hasComma(items) {
+		_, err = fmt.Fprintf(w, "%s=%s\n", key, quoteValue(EscapeValue(strings.Join(items, ","))))
+		return
+	}
+	// 只有一个元素时写成一行仍会被拆开, 没法原样读回
+	if len(items) == 1 {
+		return fmt.Errorf("key %s: single item %q contains a comma and would be split on load", key, items[0])
+	}
+	for _, item := range items {
+		if _, err = fmt.Fprintf(w, "%s=%s\n", key, quoteValue(EscapeValue(item))); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// hasComma 判断是否有元素含逗号
+func hasComma(items []string) bool {
+	for _, item := range items {
+		if strings.Contains(item, ",") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeMap 把 map 按键排序后写成 key=value, 保证输出稳定
+func writeMap(w io.Writer, mValue reflect.Value) (err error) {
+	keys := mValue.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	for _, k := range keys {
+		value, ok := formatValue(mValue.MapIndex(k), "")
+		if !ok {
+			continue
+		}
+		if _, err = fmt.Fprintf(w, "%s=%s\n", k.String(), quoteValue(EscapeValue(value))); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// formatValue 把字段的值格式化成 ini 中的字符串, 第二个返回值表示是否支持该类型
+// nil 指针返回 false, 写出时跳过, 读回来仍是 nil
+func formatValue(fieldObj reflect.Value, tag reflect.StructTag) (string, bool) {
+	switch fieldObj.Type() {
+	case durationType:
+		return time.Duration(fieldObj.Int()).String(), true
+	case timeType:
+		return fieldObj.Interface().(time.Time).Format(timeLayout(tag)), true
+	case urlType:
+		u := fieldObj.Interface().(url.URL)
+		return u.String(), true
+	}
+	if fieldObj.Kind() == reflect.Ptr {
+		if fieldObj.IsNil() {
+			return "", false
+		}
+		return formatValue(fieldObj.Elem(), tag)
+	}
+	// 实现了 encoding.TextMarshaler 的类型, 如 net.IP
+	if fieldObj.CanAddr() {
+		fieldObj = fieldObj.Addr()
+	}
+	if fieldObj.Type().Implements(textMarshalerType) {
+		text, err := fieldObj.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err == nil
+	}
+	fieldObj = reflect.Indirect(fieldObj)
+	switch fieldObj.Kind() {
+	case reflect.String:
+		return fieldObj.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fieldObj.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(fieldObj.Uint(), 10), true
+	case reflect.Bool:
+		return strconv.FormatBool(fieldObj.Bool()), true
+	case reflect.Float32, reflect.Float64:
+		// 用字段本身的精度格式化, 保证 load→save→load 得到相同的值
+		return strconv.FormatFloat(fieldObj.Float(), 'g', -1, fieldObj.Type().Bits()), true
+	}
+	return "", false
+}
@@ -0,0 +1,130 @@
+package iniparser
+
+import (
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSaveIniRoundTrip 写出再读回来, 结构体和原来完全一样
+func TestSaveIniRoundTrip(t *testing.T) {
+	want := Config{
+		MySQLConfig: MySQLConfig{Address: "10.20.30.40", Port: 3306, Username: "root", Password: "pa$word%(x)s${HOME}%%", Database: "goDB", Charset: "utf8mb4,utf8", Timeout: 5 * time.Second, TLS: "preferred", MaxIdleConns: 2},
+		RedisConfig: RedisConfig{Host: "127.0.0.1", Port: 6379, Password: " #r;o\"ot\\ ", Database: "0", Test: true},
+	}
+	b, err := MarshalIni(&want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileName := filepath.Join(t.TempDir(), "config.ini")
+	if err = ioutil.WriteFile(fileName, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var got Config
+	if err = LoadIni(fileName, &got); err != nil {
+		t.Fatalf("%v\n%s", err, b)
+	}
+	if got != want {
+		t.Errorf("round trip changed the struct\ngot  %#v\nwant %#v\n%s", got, want, b)
+	}
+}
+
+// TestSaveIniSliceMap 切片和 map 写出再读回来, 含逗号的元素写成重复的键
+func TestSaveIniSliceMap(t *testing.T) {
+	type redis struct {
+		Hosts []string `ini:"hosts"`
+		Ports []int `ini:"ports"`
+		Flags []bool `ini:"flags"`
+		Notes []string `ini:"notes"`
+		Empty []string `ini:"empty"`
+	}
+	type config struct {
+		Redis redis `ini:"redis"`
+		Options map[string]string `ini:"options"`
+	}
+	want := config{
+		Redis: redis{
+			Hosts: []string{"10.0.0.1", "10.0.0.2"},
+			Ports: []int{6379, 6380},
+			Flags: []bool{true, false},
+			Notes: []string{"a,b", "c$"},
+		},
+		Options: map[string]string{"timeout": "5s", "charset": "utf8,latin1", "cost": "$5 100%"},
+	}
+	b, err := MarshalIni(&want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "notes=a,b\nnotes=c$$\n") {
+		t.Errorf("items with a comma should be written as repeated keys\n%s", b)
+	}
+	fileName := filepath.Join(t.TempDir(), "config.ini")
+	if err = ioutil.WriteFile(fileName, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var got config
+	if err = LoadIni(fileName, &got); err != nil {
+		t.Fatalf("%v\n%s", err, b)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip changed the struct\ngot  %#v\nwant %#v\n%s", got, want, b)
+	}
+	// 只有一个含逗号的元素时没法原样写出
+	want.Redis.Notes = []string{"a,b"}
+	if _, err = MarshalIni(&want); err == nil {
+		t.Error("a single item with a comma should be rejected")
+	}
+}
+
+// TestSaveIniValueTypes Duration, Time, URL, 无符号整数和指针写出再读回来不变
+func TestSaveIniValueTypes(t *testing.T) {
+	type replica struct {
+		Address string `ini:"address"`
+		Weight *int `ini:"weight"`
+	}
+	type database struct {
+		Timeout time.Duration `ini:"timeout"`
+		Started time.Time `ini:"started"`
+		Day time.Time `ini:"day" layout:"2006-01-02"`
+		Endpoint url.URL `ini:"endpoint"`
+		Ratio float64 `ini:"ratio"`
+		Size uint16 `ini:"size"`
+		Retries *uint `ini:"retries"`
+		Delays []time.Duration `ini:"delays"`
+		Replica *replica `ini:"replica"`
+	}
+	type config struct {
+		Name string `ini:"name"`
+		Database database `ini:"database"`
+	}
+	weight := 3
+	endpoint, _ := url.Parse("https://example.com/path?q=1%202")
+	want := config{
+		Name: "demo",
+		Database: database{
+			Timeout: 90 * time.Second,
+			Started: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+			Day: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			Endpoint: *endpoint,
+			Ratio: 0.25,
+			Size: 512,
+			Delays: []time.Duration{time.Second, 1500 * time.Millisecond},
+			Replica: &replica{Address: "10.0.0.2", Weight: &weight},
+		},
+	}
+	b, err := MarshalIni(&want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got config
+	if err = Unmarshal(b, &got); err != nil {
+		t.Fatalf("%v\n%s", err, b)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip changed the struct\ngot  %#v\nwant %#v\n%s", got, want, b)
+	}
+}
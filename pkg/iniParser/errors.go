@@ -0,0 +1,110 @@
+package iniparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrSyntax 行的格式不对, 如缺少 ] 或 =
+var ErrSyntax = errors.New("syntax error")
+
+// ParseError 解析或赋值时出错的位置和原因, 可以用 errors.As 取出
+//   var pe *ParseError
+//   if errors.As(err, &pe) { fmt.Println(pe.File, pe.Line, pe.Column) }
+type ParseError struct {
+	// File 出错的文件, 从字节或 io.Reader 解析时为空
+	File string
+	// Line 和 Column 从 1 开始, Column 按字符计算
+	Line int
+	Column int
+	Section string
+	Key string
+	// Raw 出错的那一行的原始内容
+	Raw string
+	// Err 具体的原因, 如 ErrSyntax 或 *strconv.NumError
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	var b strings.Builder
+	b.WriteString(position{e.File, e.Line}.String())
+	if e.Column > 0 {
+		fmt.Fprintf(&b, ":%d", e.Column)
+	}
+	b.WriteString(": ")
+	switch {
+	case len(e.Key) > 0 && len(e.Section) > 0:
+		fmt.Fprintf(&b, "[%s] %s: ", e.Section, e.Key)
+	case len(e.Key) > 0:
+		fmt.Fprintf(&b, "%s: ", e.Key)
+	case len(e.Section) > 0:
+		fmt.Fprintf(&b, "[%s]: ", e.Section)
+	}
+	b.WriteString(e.Err.Error())
+	if len(e.Raw) > 0 {
+		fmt.Fprintf(&b, " - \"%s\"", e.Raw)
+	}
+	return b.String()
+}
+
+// Unwrap 返回具体的原因
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// lineError 生成一行格式不对时的 ParseError, offset 是出错位置在 raw 中的字节下标
+func lineError(sec *Section, line int, raw string, offset int, err error) *ParseError {
+	return &ParseError{
+		File: sec.File,
+		Line: line,
+		Column: utf8.RuneCountInString(raw[:offset]) + 1,
+		Section: sec.Name,
+		Raw: strings.TrimRight(raw, "\r"),
+		Err: err,
+	}
+}
+
+// sectionError 生成节有问题时的 ParseError, 指向节标题那一行
+func sectionError(sec *Section, err error) *ParseError {
+	pe := &ParseError{
+		File: sec.File,
+		Line: sec.Line,
+		Section: sec.Name,
+		Err: err,
+	}
+	for _, dl := range sec.head {
+		if dl.kind == sectionLine {
+			pe.Raw = strings.TrimRight(dl.raw, "\r")
+			pe.Column = utf8.RuneCountInString(dl.raw[:strings.Index(dl.raw, "[")]) + 1
+		}
+	}
+	return pe
+}
+
+// keyError 生成键的值有问题时的 ParseError, 列号指向值的开头
+func keyError(sec *Section, k *Key, err error) *ParseError {
+	pe := &ParseError{
+		File: sec.File,
+		Line: k.Line,
+		Section: sec.Name,
+		Key: k.Name,
+		Err: err,
+	}
+	if k.line != nil && len(k.line.raw) > 0 {
+		pe.Raw = strings.TrimRight(k.line.raw, "\r")
+		pe.Column = utf8.RuneCountInString(k.line.raw[:k.line.valStart]) + 1
+	}
+	return pe
+}
+
+// keyNameError 和 keyError 一样, 但列号指向键名, 用于未知和重复的键
+func keyNameError(sec *Section, k *Key, err error) *ParseError {
+	pe := keyError(sec, k, err)
+	if pe.Column > 0 {
+		raw := k.line.raw
+		pe.Column = utf8.RuneCountInString(raw[:len(raw)-len(strings.TrimLeft(raw, " \t"))]) + 1
+	}
+	return pe
+}
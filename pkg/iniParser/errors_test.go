@@ -0,0 +1,69 @@
+package iniparser
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+type errorsConfig struct {
+	MySQL struct {
+		Address string `ini:"address"`
+		Port int `ini:"port"`
+		Ratio float64 `ini:"ratio"`
+	} `ini:"mysql"`
+}
+
+// TestParseError 出错的位置和原因可以用 errors.As 取出
+func TestParseError(t *testing.T) {
+	cases := []struct {
+		name string
+		content string
+		line int
+		column int
+		section string
+		key string
+		cause error
+	}{
+		{"missing ]", "[mysql]\nport=1\n  [redis\n", 3, 3, "mysql", "", ErrSyntax},
+		{"missing =", "[mysql]\n\taddress\n", 2, 2, "mysql", "", ErrSyntax},
+		{"global section", "name\n", 1, 1, "", "", ErrSyntax},
+		// 行号不能被 = 的下标覆盖
+		{"bad int", "; comment\n[mysql]\naddress=db\n\nport = 中文\n", 5, 8, "mysql", "port", strconv.ErrSyntax},
+		{"bad float", "[mysql]\nratio=1e999\n", 2, 7, "mysql", "ratio", strconv.ErrRange},
+		{"unset variable", "[mysql]\naddress= ${INI_TEST_UNSET}\n", 2, 10, "mysql", "address", nil},
+	}
+	for _, c := range cases {
+		err := Unmarshal([]byte(c.content), &errorsConfig{})
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("%s: expected a *ParseError, got %v", c.name, err)
+			continue
+		}
+		if pe.Line != c.line || pe.Column != c.column || pe.Section != c.section || pe.Key != c.key {
+			t.Errorf("%s: got %d:%d [%s] %s, want %d:%d [%s] %s", c.name, pe.Line, pe.Column, pe.Section, pe.Key, c.line, c.column, c.section, c.key)
+		}
+		if c.cause != nil && !errors.Is(err, c.cause) {
+			t.Errorf("%s: %v should wrap %v", c.name, err, c.cause)
+		}
+	}
+}
+
+// TestParseErrorNumError 值的格式不对时保留 strconv 的错误, 文件名来自 LoadIni
+func TestParseErrorNumError(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"config.ini": "[mysql]\nport=abc\n"})
+	fileName := filepath.Join(dir, "config.ini")
+	err := LoadIni(fileName, &errorsConfig{})
+	var ne *strconv.NumError
+	if !errors.As(err, &ne) || ne.Num != "abc" {
+		t.Fatalf("expected a wrapped *strconv.NumError, got %v", err)
+	}
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.File != fileName || pe.Raw != "port=abc" {
+		t.Errorf("got %+v", pe)
+	}
+	if want := fileName + ":2:6: [mysql] port: strconv.ParseInt: parsing \"abc\": invalid syntax - \"port=abc\""; err.Error() != want {
+		t.Errorf("got  %q\nwant %q", err.Error(), want)
+	}
+}
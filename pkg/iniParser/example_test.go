@@ -0,0 +1,55 @@
+package iniparser_test
+
+import (
+	"fmt"
+	iniparser "github.com/pastaTree/goExercise/pkg/iniParser"
+	"strings"
+	"testing/fstest"
+)
+
+// 其他包通过导出的 API 使用解析器: 嵌入的文件, HTTP 请求体, 测试数据
+
+func ExampleDecode() {
+	var cfg iniparser.Config
+	body := strings.NewReader("[mysql]\naddress=db.local\nusername=app\n")
+	if err := iniparser.Decode(body, &cfg); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(cfg.MySQLConfig.Address, cfg.MySQLConfig.Port, cfg.RedisConfig.Port)
+	// Output: db.local 3306 6379
+}
+
+func ExampleUnmarshal() {
+	var cfg iniparser.Config
+	if err := iniparser.Unmarshal([]byte("[mysql]\naddress=db.local\n"), &cfg); err != nil {
+		fmt.Println(err)
+	}
+	// Output: missing required keys: [mysql] username
+}
+
+func ExampleLoadFS() {
+	fsys := fstest.MapFS{
+		"base.ini": {Data: []byte("[mysql]\naddress=db.local\nusername=app\n")},
+		"app.ini": {Data: []byte("include=base.ini\n\n[mysql]\nport=3307\n")},
+	}
+	var cfg iniparser.Config
+	if err := iniparser.LoadFS(fsys, "app.ini", &cfg); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(cfg.MySQLConfig.Address, cfg.MySQLConfig.Port)
+	// Output: db.local 3307
+}
+
+func ExampleParseFile() {
+	f, err := iniparser.ParseFile([]byte("[plugin.cache]\nsize=128\nttl=1m30s\n"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	size, _ := f.GetInt("plugin.cache.size", 64)
+	ttl, _ := f.GetDuration("plugin.cache.ttl", 0)
+	fmt.Println(size, ttl)
+	// Output: 128 1m30s
+}
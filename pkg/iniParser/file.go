@@ -0,0 +1,256 @@
+package iniparser
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// 不需要结构体的访问方式, 插件等可以直接读取自己的节
+//   f, err := LoadFile("config.ini")
+//   port, err := f.GetInt("mysql.port", 3306)
+//   for _, sec := range f.Sections() {
+//       for _, key := range sec.Keys() { value, _ := sec.Get(key) }
+//   }
+// 和 LoadIni 使用同一个解析器: include, 节的继承, 环境(Options.Profile)和变量展开都一样
+// 同名的节合并成一个, 同名的键以最后出现的为准, 节中没有的键到 [DEFAULT] 中找
+// 值格式不对时返回带位置的 *ParseError, 键不存在时返回默认值
+
+// File 加载后的 ini 文件
+type File struct {
+	sections []*FileSection
+	// fold 节名和键名忽略大小写, 来自 Options.CaseInsensitiveKeys
+	fold bool
+}
+
+// FileSection File 中的一个节
+type FileSection struct {
+	// Name 节名, 嵌套的节写成 mysql.replica, 全局节为空
+	Name string
+	// names 键名按第一次出现的顺序, keys 中是最后出现的键
+	names []string
+	keys map[string]fileKey
+	// defaults 是 [DEFAULT] 节, 本节中没有的键到这里找
+	defaults *FileSection
+	fold bool
+}
+
+// fileKey 键和它所在的节, 节用于报错时的位置
+type fileKey struct {
+	key *Key
+	sec *Section
+	value string
+}
+
+// LoadFile 加载本地文件, 和 LoadIni 一样会读入 include 的文件
+func LoadFile(fileName string, opts ...Options) (*File, error) {
+	o := mergeOptions(opts)
+	doc, err := readDocument(fileName, o.Format)
+	if err != nil {
+		return nil, err
+	}
+	return newFile(doc, o)
+}
+
+// ParseFile 从字节解析, 和 Unmarshal 一样不支持 include
+func ParseFile(b []byte, opts ...Options) (*File, error) {
+	o := mergeOptions(opts)
+	doc, err := parseBytes(b, o.Format)
+	if err != nil {
+		return nil, err
+	}
+	return newFile(doc, o)
+}
+
+// newFile 按环境选择节, 展开继承和变量后, 把文档整理成 File
+func newFile(doc *Document, opts Options) (*File, error) {
+	doc, err := resolveSections(doc, opts.Profile)
+	if err != nil {
+		return nil, err
+	}
+	// 所有的键都可以通过 Get 取到, 所以全部展开, 出错时加载就失败
+	ip := newInterpolator(doc)
+	f := &File{fold: opts.CaseInsensitiveKeys}
+	f.sections = append(f.sections, f.newSection(""))
+	for _, sec := range doc.Sections() {
+		name := sec.Name
+		if len(name) > 0 {
+			name = strings.Join(sectionPath(name), ".")
+		}
+		fs := f.lookup(name)
+		if fs == nil {
+			fs = f.newSection(name)
+			f.sections = append(f.sections, fs)
+		}
+		for _, k := range sec.Keys() {
+			if len(sec.Name) == 0 && k.Name == includeKey {
+				continue
+			}
+			value, err := ip.resolve(k)
+			if err != nil {
+				return nil, err
+			}
+			id := fs.id(k.Name)
+			if _, ok := fs.keys[id]; !ok {
+				fs.names = append(fs.names, k.Name)
+			}
+			fs.keys[id] = fileKey{key: k, sec: sec, value: value}
+		}
+	}
+	// 除了全局节和 [DEFAULT] 自己, 其余的节都继承 [DEFAULT]
+	if defaults := f.lookup(defaultSection); defaults != nil {
+		for _, fs := range f.sections {
+			if len(fs.Name) > 0 && fs != defaults {
+				fs.defaults = defaults
+			}
+		}
+	}
+	return f, nil
+}
+
+func (f *File) newSection(name string) *FileSection {
+	return &FileSection{Name: name, keys: make(map[string]fileKey), fold: f.fold}
+}
+
+// lookup 返回名为 name 的节, 不存在时返回 nil
+func (f *File) lookup(name string) *FileSection {
+	for _, fs := range f.sections {
+		if matchName(fs.Name, name, f.fold) || (len(fs.Name) == 0 && len(name) == 0) {
+			return fs
+		}
+	}
+	return nil
+}
+
+// Sections 按第一次出现的顺序返回所有节, 第一个是全局节
+func (f *File) Sections() []*FileSection {
+	return f.sections
+}
+
+// HasSection 判断文件中是否有名为 name 的节
+func (f *File) HasSection(name string) bool {
+	return f.lookup(name) != nil
+}
+
+// Section 返回名为 name 的节, 不存在时返回一个空节, 可以直接在上面取默认值
+func (f *File) Section(name string) *FileSection {
+	if fs := f.lookup(name); fs != nil {
+		return fs
+	}
+	return f.newSection(name)
+}
+
+// split 把 section.key 拆开并找到节
+func (f *File) split(path string) (*FileSection, string) {
+	section, key := splitKeyPath(path)
+	return f.Section(section), key
+}
+
+// Get 返回 section.key 的值, 全局的键只写 key
+func (f *File) Get(path string) (string, bool) {
+	fs, key := f.split(path)
+	return fs.Get(key)
+}
+
+// GetString 返回 section.key 的值, 不存在时返回 def
+func (f *File) GetString(path, def string) string {
+	fs, key := f.split(path)
+	return fs.GetString(key, def)
+}
+
+// GetInt 返回 section.key 的整数值, 不存在时返回 def
+func (f *File) GetInt(path string, def int) (int, error) {
+	fs, key := f.split(path)
+	return fs.GetInt(key, def)
+}
+
+// GetBool 返回 section.key 的布尔值, 不存在时返回 def
+func (f *File) GetBool(path string, def bool) (bool, error) {
+	fs, key := f.split(path)
+	return fs.GetBool(key, def)
+}
+
+// GetDuration 返回 section.key 的时长, 如 1m30s, 不存在时返回 def
+func (f *File) GetDuration(path string, def time.Duration) (time.Duration, error) {
+	fs, key := f.split(path)
+	return fs.GetDuration(key, def)
+}
+
+// id 键在 keys 中的名字, 忽略大小写时统一成小写
+func (s *FileSection) id(key string) string {
+	if s.fold {
+		return strings.ToLower(key)
+	}
+	return key
+}
+
+// Keys 按第一次出现的顺序返回节中的键名, 之后是从 [DEFAULT] 继承的键
+func (s *FileSection) Keys() []string {
+	names := append([]string{}, s.names...)
+	if s.defaults != nil {
+		for _, name := range s.defaults.names {
+			if _, ok := s.keys[s.id(name)]; !ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// find 找到键, 本节没有时到 [DEFAULT] 中找
+func (s *FileSection) find(key string) (fileKey, bool) {
+	if fk, ok := s.keys[s.id(key)]; ok {
+		return fk, true
+	}
+	if s.defaults != nil {
+		return s.defaults.find(key)
+	}
+	return fileKey{}, false
+}
+
+// Get 返回键的值
+func (s *FileSection) Get(key string) (string, bool) {
+	fk, ok := s.find(key)
+	return fk.value, ok
+}
+
+// GetString 返回键的值, 不存在时返回 def
+func (s *FileSection) GetString(key, def string) string {
+	if value, ok := s.Get(key); ok {
+		return value
+	}
+	return def
+}
+
+// GetInt 返回键的整数值, 不存在时返回 def
+func (s *FileSection) GetInt(key string, def int) (int, error) {
+	n := def
+	err := s.parse(key, &n)
+	return n, err
+}
+
+// GetBool 返回键的布尔值, 不存在时返回 def
+func (s *FileSection) GetBool(key string, def bool) (bool, error) {
+	b := def
+	err := s.parse(key, &b)
+	return b, err
+}
+
+// GetDuration 返回键的时长, 不存在时返回 def
+func (s *FileSection) GetDuration(key string, def time.Duration) (time.Duration, error) {
+	d := def
+	err := s.parse(key, &d)
+	return d, err
+}
+
+// parse 和 LoadIni 一样用 setValue 转换键的值, 存到 dst 指向的变量中, 键不存在时不修改
+func (s *FileSection) parse(key string, dst interface{}) error {
+	fk, ok := s.find(key)
+	if !ok {
+		return nil
+	}
+	if err := setValue(reflect.ValueOf(dst).Elem(), fk.value, ""); err != nil {
+		return keyError(fk.sec, fk.key, err)
+	}
+	return nil
+}
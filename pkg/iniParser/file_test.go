@@ -0,0 +1,120 @@
+package iniparser
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const fileIni = `name=app
+
+[DEFAULT]
+timeout=5s
+prefix=app
+
+[plugin.cache]
+size=128
+enabled=true
+size=256
+
+[plugin "cache"]
+ttl=1m30s
+path=%(prefix)s-cache
+
+[plugin.search]
+size=oops
+timeout=1s
+`
+
+// TestFile 同名的节合并, 键以最后出现的为准, 按第一次出现的顺序迭代, 没有的键到 [DEFAULT] 中找
+func TestFile(t *testing.T) {
+	f, err := ParseFile([]byte(fileIni))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, sec := range f.Sections() {
+		names = append(names, sec.Name)
+	}
+	if want := []string{"", "DEFAULT", "plugin.cache", "plugin.search"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("sections %v, want %v", names, want)
+	}
+	cache := f.Section("plugin.cache")
+	if want := []string{"size", "enabled", "ttl", "path", "timeout", "prefix"}; !reflect.DeepEqual(cache.Keys(), want) {
+		t.Errorf("keys %v, want %v", cache.Keys(), want)
+	}
+	if size, err := f.GetInt("plugin.cache.size", 0); err != nil || size != 256 {
+		t.Errorf("size = %d, %v, want 256", size, err)
+	}
+	if ttl, err := f.GetDuration("plugin.cache.ttl", 0); err != nil || ttl != 90*time.Second {
+		t.Errorf("ttl = %v, %v, want 1m30s", ttl, err)
+	}
+	if timeout, err := f.GetDuration("plugin.cache.timeout", 0); err != nil || timeout != 5*time.Second {
+		t.Errorf("timeout = %v, %v, want 5s from [DEFAULT]", timeout, err)
+	}
+	if enabled, err := f.GetBool("plugin.cache.enabled", false); err != nil || !enabled {
+		t.Errorf("enabled = %v, %v, want true", enabled, err)
+	}
+	if path := f.GetString("plugin.cache.path", ""); path != "app-cache" {
+		t.Errorf("path = %q, want app-cache", path)
+	}
+	if name, ok := f.Get("name"); !ok || name != "app" {
+		t.Errorf("name = %q, %v, want app", name, ok)
+	}
+	if _, ok := f.Get("timeout"); ok {
+		t.Error("the global section should not inherit [DEFAULT]")
+	}
+}
+
+// TestFileDefaults 键或节不存在时返回默认值, 值格式不对时返回带位置的错误
+func TestFileDefaults(t *testing.T) {
+	f, err := ParseFile([]byte(fileIni))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.HasSection("plugin.mail") {
+		t.Error("plugin.mail should not exist")
+	}
+	if port, err := f.GetInt("plugin.mail.port", 25); err != nil || port != 25 {
+		t.Errorf("port = %d, %v, want the default 25", port, err)
+	}
+	if host := f.Section("plugin.mail").GetString("host", "localhost"); host != "localhost" {
+		t.Errorf("host = %q, want the default", host)
+	}
+	size, err := f.GetInt("plugin.search.size", 64)
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Line != 17 || pe.Key != "size" {
+		t.Fatalf("got %v, want a ParseError for size on line 17", err)
+	}
+	var ne *strconv.NumError
+	if !errors.As(err, &ne) || size != 64 {
+		t.Errorf("got %v and %d, want a *strconv.NumError and the default", err, size)
+	}
+}
+
+// TestLoadFile 和 LoadIni 一样支持 include, 环境和忽略大小写, 从字节解析时不支持 include
+func TestLoadFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.ini": "[MySQL]\nPort=3306\n",
+		"config.ini": "include=base.ini\n\n[mysql@prod : MySQL]\nport=3307\n",
+	})
+	f, err := LoadFile(filepath.Join(dir, "config.ini"), Options{Profile: "prod", CaseInsensitiveKeys: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port, err := f.GetInt("mysql.PORT", 0); err != nil || port != 3307 {
+		t.Errorf("port = %d, %v, want 3307", port, err)
+	}
+	if _, ok := f.Get("include"); ok {
+		t.Error("include should not be a key")
+	}
+	if _, err := ParseFile([]byte("include=base.ini\n")); err == nil {
+		t.Error("expected an error for include without a file system")
+	}
+	if _, err := ParseFile([]byte("[a]\nb=${INI_TEST_NOT_SET}\n")); err == nil {
+		t.Error("expected an error for an unset environment variable")
+	}
+}
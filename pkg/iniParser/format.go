@@ -0,0 +1,112 @@
+package iniparser
+
+import (
+	"sort"
+	"strings"
+)
+
+// Format 返回文档格式化后的内容, 值和注释不变, 只改变排版
+//   全局节在最前面, [DEFAULT] 其次, 其余的节按名字排序, 同名的节保持原来的先后
+//   节中的键按名字排序, 重复的键保持原来的先后, 键上方的注释跟着键走
+//   写成 key = value, 注释和键之间不留空行, 节之间空一行
+// 原文件的换行符和 BOM 保留
+func (doc *Document) Format() []byte {
+	sections := append([]*Section{}, doc.sections...)
+	sort.SliceStable(sections, func(i, j int) bool {
+		ri, rj := sectionRank(sections[i]), sectionRank(sections[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return sections[i].Name < sections[j].Name
+	})
+	var lines []string
+	for _, sec := range sections {
+		block := formatSection(sec)
+		if len(block) == 0 {
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, block...)
+	}
+	end := "\n"
+	if doc.crlf {
+		end = "\r\n"
+	}
+	var b strings.Builder
+	if doc.bom {
+		b.WriteString(bom)
+	}
+	for _, line := range lines {
+		b.WriteString(line + end)
+	}
+	return []byte(b.String())
+}
+
+// sectionRank 节排序时的分组, 全局节最前, 然后是 [DEFAULT]
+func sectionRank(sec *Section) int {
+	switch sec.Name {
+	case "":
+		return 0
+	case defaultSection:
+		return 1
+	}
+	return 2
+}
+
+// formatSection 返回一个节格式化后的行, 不含换行符
+func formatSection(sec *Section) []string {
+	var lines []string
+	for _, dl := range sec.head {
+		switch dl.kind {
+		case commentLine:
+			lines = append(lines, strings.TrimSpace(dl.raw))
+		case sectionLine:
+			header := "[" + sec.Name + "]"
+			if len(sec.Parent) > 0 {
+				header = "[" + sec.Name + " : " + sec.Parent + "]"
+			}
+			// 保留 ] 之后的行内注释
+			line := strings.TrimSpace(dl.raw)
+			lines = append(lines, joinComment(header, line[sectionEnd(line)+1:]))
+		}
+	}
+	// groups 每个键和它上方的注释, tail 是最后一个键之后的注释
+	type keyGroup struct {
+		name string
+		lines []string
+	}
+	var groups []keyGroup
+	var pending []string
+	for _, dl := range sec.body {
+		switch dl.kind {
+		case commentLine:
+			pending = append(pending, strings.TrimSpace(dl.raw))
+		case keyLine:
+			k := dl.key
+			line := k.Name + " = " + quoteValue(k.Value)
+			if len(dl.raw) > 0 {
+				line = joinComment(line, dl.raw[dl.valEnd:])
+			}
+			groups = append(groups, keyGroup{name: k.Name, lines: append(pending, line)})
+			pending = nil
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].name < groups[j].name
+	})
+	for _, g := range groups {
+		lines = append(lines, g.lines...)
+	}
+	return append(lines, pending...)
+}
+
+// joinComment 把原行中值之后的行内注释接到格式化后的行后面
+func joinComment(line, rest string) string {
+	rest = strings.TrimSpace(rest)
+	if len(rest) == 0 || !isComment(rest[0]) {
+		return line
+	}
+	return line + " " + rest
+}
@@ -0,0 +1,49 @@
+package iniparser
+
+import (
+	"testing"
+)
+
+// TestFormat 节和键排序, 注释跟着键走, 换行符和 BOM 保留, 再格式化一次结果不变
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		in string
+		want string
+	}{
+		{
+			"sort",
+			"name=app\n[redis]\nport=6379\n\n\n[mysql]  ; main db\n; the port\nport   =  3306\naddress=db # host\n; trailing\n\n[DEFAULT]\ntimeout=5\n",
+			"name = app\n\n[DEFAULT]\ntimeout = 5\n\n[mysql] ; main db\naddress = db # host\n; the port\nport = 3306\n; trailing\n\n[redis]\nport = 6379\n",
+		},
+		{
+			"repeated keys and quoted values",
+			"[mysql]\nhosts=b\nhosts=a\nalias=x\npassword = \" p;w \"\n",
+			"[mysql]\nalias = x\nhosts = b\nhosts = a\npassword = \" p;w \"\n",
+		},
+		{
+			"crlf and bom",
+			"\ufeff[b]\r\nk=1\r\n[a : b]\r\nk=2\r\n",
+			"\ufeff[a : b]\r\nk = 2\r\n\r\n[b]\r\nk = 1\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseDocument([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := string(doc.Format())
+			if got != tt.want {
+				t.Fatalf("got\n%q\nwant\n%q", got, tt.want)
+			}
+			doc, err = ParseDocument([]byte(got))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again := string(doc.Format()); again != got {
+				t.Errorf("formatting twice changed the result\n%q", again)
+			}
+		})
+	}
+}
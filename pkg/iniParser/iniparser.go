@@ -0,0 +1,481 @@
+// Package iniparser ini配置文件解析器, 按 ini tag 把配置文件解析到结构体中
+// 命令行工具 initool 见 cmd/initool
+package iniparser
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadIni 从本地文件加载配置, 和 LoadFS 一样会读入 include 的文件
+// opts 可选, 默认忽略结构体中没有的节和键
+func LoadIni(fileName string, data interface{}, opts ...Options) (err error) {
+	// 1. 读取并解析文件, include 的文件也一起读进来, 格式按扩展名或 Options.Format 选择
+	o := mergeOptions(opts)
+	doc, err := readDocument(fileName, o.Format)
+	if err != nil {
+		return
+	}
+	// 2. 把文档中的键值对赋给结构体
+	return decodeDocument(doc, data, o)
+}
+
+// decodeDocument 把解析好的文档按 ini tag 赋给 data 指向的结构体
+func decodeDocument(doc *Document, data interface{}, opts Options) (err error) {
+	// 0. 参数的校验
+	// 传入的 data 必须是指针类型(需要赋值)
+	t := reflect.TypeOf(data)
+	if t == nil || t.Kind() != reflect.Ptr {
+		err = errors.New("input should be a pointer")  // 创建一个 error 类型的错误
+		return
+	}
+	// 传入的 data 必须是结构体类型的指针(需要把各种键值对赋值给结构体)
+	if t.Elem().Kind() != reflect.Struct {
+		err = errors.New("input should be a struct")
+		return
+	}
+	// 1. 先把 default tag 的默认值赋上, 文件中有的键会覆盖它们
+	if err = applyDefaults(reflect.ValueOf(data).Elem()); err != nil {
+		return
+	}
+	// 2. 按环境选择节并展开节的继承, 值中的环境变量和对其他键的引用在赋值时才展开, 没有对应字段的键不展开也不报错
+	if doc, err = resolveSections(doc, opts.Profile); err != nil {
+		return
+	}
+	ip := newInterpolator(doc)
+	// 3. 一个个节分析数据, lines 记录赋过值的键所在的位置, 用来检查必填字段和报告校验错误
+	// 同一个键出现在多个节中时(如分层加载), 后出现的覆盖前面的
+	lines := make(map[string]position)
+	// strictErrs 收集未知和重复的节和键, 除了全局的未知键只在严格模式下收集, sections 用来发现同一个文件中重复的节
+	var strictErrs ParseErrors
+	sections := make(map[string]bool)
+	// defaults 是 [DEFAULT] 节, visited 是出现过的结构体节, 最后把 [DEFAULT] 的键补给它们
+	var defaults []*Section
+	var visited []visitedSection
+	secret := newSecrets(opts.Keys)
+	root := reflect.ValueOf(data).Elem()
+	for _, sec := range doc.Sections() {
+		var path []string
+		var sValue reflect.Value
+		switch {
+		case len(sec.Name) == 0:
+			// 3.1 第一个 [section] 之前的全局键对应 data 顶层的字段
+			sValue = root
+		case sec.Name == defaultSection:
+			defaults = append(defaults, sec)
+			continue
+		default:
+			// 3.2 根据节名的路径找到对应的(嵌套)结构体, 如 [mysql] [mysql.replica] [mysql "replica"]
+			path = sectionPath(sec.Name)
+			index, ok := sectionIndex(t.Elem(), path, opts.CaseInsensitiveKeys)
+			if !ok {
+				// 在 data 中找不到对应的节, 从父节复制来的节由子节自己报告
+				if opts.Strict && !sec.inherited {
+					strictErrs = append(strictErrs, sectionError(sec, ErrUnknownSection))
+				}
+				continue
+			}
+			// 忽略大小写时文件中的写法可能和 tag 不同, 之后统一用 tag 中的名字
+			path = tagPath(t.Elem(), index)
+			if opts.Strict && !sec.inherited {
+				id := sec.File + "\x00" + fmt.Sprint(index)
+				if sections[id] {
+					strictErrs = append(strictErrs, sectionError(sec, ErrDuplicateSection))
+				}
+				sections[id] = true
+			}
+			// 沿着路径去 data 中把对应的嵌套结构体取出来, 途中的结构体指针按需分配
+			sValue, err = fieldByIndexAlloc(root, index) //拿到嵌套结构体的值信息
+			if err != nil {
+				return
+			}
+		}
+		sType := sValue.Type() // 拿到嵌套结构体的类型信息
+		// map 类型的字段, 整个节的键值对都放进 map
+		if sType.Kind() == reflect.Map {
+			if opts.Strict && !opts.AllowDuplicateKeys && !sec.inherited {
+				for _, k := range duplicateKeys(sec, opts.CaseInsensitiveKeys) {
+					strictErrs = append(strictErrs, keyNameError(sec, k, ErrDuplicateKey))
+				}
+			}
+			if err = setMap(sValue, sec, ip); err != nil {
+				return
+			}
+			continue
+		}
+		// 判断 config 中的字段是否是个结构体
+		if sType.Kind() != reflect.Struct {
+			err = fmt.Errorf("data 中的%s字段应该是个结构体", sec.Name)
+			return
+		}
+		visited = append(visited, visitedSection{path: path, value: sValue})
+		plan := planFor(sType)
+		// seen 记录本节中已经赋过值的字段, repeated 记录本节中出现多次的键
+		seen := make(map[int]bool)
+		repeated := repeatedKeys(sec, opts.CaseInsensitiveKeys)
+		for _, k := range sec.Keys() {
+			if len(sec.Name) == 0 && k.Name == includeKey {
+				continue
+			}
+			// 3.3 在缓存的解析计划中找 tag 等于 key 的字段
+			fp, ok := plan.key(k.Name, opts.CaseInsensitiveKeys)
+			if !ok {
+				// 在结构体中找不到对应的字段, 第一个节之前的键不论是否严格模式都要报告,
+				// 否则写错名字或忘了写节名的键会被悄悄丢掉, 从父节继承来的键不报告
+				if (opts.Strict || len(sec.Name) == 0) && !opts.AllowUnknownKeys && !sec.inherited {
+					strictErrs = append(strictErrs, keyNameError(sec, k, ErrUnknownKey))
+				}
+				continue
+			}
+			// 3.4 取出这个字段并赋值
+			if fp.section {
+				err = keyError(sec, k, fmt.Errorf("%s is a section, not a key", k.Name))
+				return
+			}
+			fieldObj := sValue.Field(fp.index)
+			if seen[fp.index] && !fp.list && opts.Strict && !opts.AllowDuplicateKeys && !sec.inherited {
+				strictErrs = append(strictErrs, keyNameError(sec, k, ErrDuplicateKey))
+			}
+			var value string
+			if value, err = ip.resolve(k); err != nil {
+				return
+			}
+			// 带 secret tag 的字段先解密
+			if value, err = secret.value(value, fp.tag); err != nil {
+				err = keyError(sec, k, err)
+				return
+			}
+			if repeated[foldName(k.Name, opts.CaseInsensitiveKeys)] && fp.list {
+				// 重复的键每行是切片的一个元素, 不再按逗号拆分
+				if !seen[fp.index] {
+					fieldObj.Set(reflect.Zero(fieldObj.Type()))
+				}
+				err = appendItem(fieldObj, value, fp.tag)
+			} else {
+				err = setValue(fieldObj, value, fp.tag)
+			}
+			if err != nil {
+				err = keyError(sec, k, err)
+				return
+			}
+			seen[fp.index] = true
+			lines[sectionKey(path, fp.name)] = position{file: sec.File, line: k.Line}
+		}
+	}
+	if len(strictErrs) > 0 {
+		return strictErrs
+	}
+	// 3.5 [DEFAULT] 中的键补给出现过但没有设置这些键的节, 和 Python 的 configparser 一样
+	if err = inheritDefaults(defaults, visited, ip, lines, secret, opts); err != nil {
+		return
+	}
+	// 4. 检查必填字段, 一次列出所有缺失的键
+	if err = checkRequired(root, lines); err != nil {
+		return
+	}
+	// 5. 按 validate tag 校验, 一次返回所有违反规则的字段
+	return validateStruct(root, lines)
+}
+
+// defaultSection 其中的键会被所有结构体节继承
+const defaultSection = "DEFAULT"
+
+// visitedSection 解析过的结构体节
+type visitedSection struct {
+	path []string
+	value reflect.Value
+}
+
+// inheritDefaults 把 [DEFAULT] 节中的键赋给 visited 中还没有设置它们的字段, 全局的顶层字段除外
+func inheritDefaults(defaults []*Section, visited []visitedSection, ip *interpolator, lines map[string]position, secret *secrets, opts Options) error {
+	// inherited 记录从 [DEFAULT] 赋值的键, 后面的 [DEFAULT] 可以覆盖前面的, 但不覆盖节中写明的
+	inherited := make(map[string]bool)
+	for _, vs := range visited {
+		if len(vs.path) == 0 {
+			continue
+		}
+		plan := planFor(vs.value.Type())
+		for _, sec := range defaults {
+			for _, k := range sec.Keys() {
+				fp, ok := plan.key(k.Name, opts.CaseInsensitiveKeys)
+				if !ok || fp.section {
+					continue
+				}
+				name := sectionKey(vs.path, fp.name)
+				if _, ok := lines[name]; ok && !inherited[name] {
+					continue
+				}
+				value, err := ip.resolve(k)
+				if err != nil {
+					return err
+				}
+				value, err = secret.value(value, fp.tag)
+				if err == nil {
+					err = setValue(vs.value.Field(fp.index), value, fp.tag)
+				}
+				if err != nil {
+					return keyError(sec, k, err)
+				}
+				inherited[name] = true
+				lines[name] = position{file: sec.File, line: k.Line}
+			}
+		}
+	}
+	return nil
+}
+
+// isSectionType 判断字段类型对应一个节而不是一个键: 结构体, 结构体指针和 map
+func isSectionType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return !isValueType(t) && (t.Kind() == reflect.Struct || t.Kind() == reflect.Map)
+}
+
+// sectionPath 把节名拆成路径, "mysql.replica" 和 `mysql "replica"` 都得到 [mysql replica]
+func sectionPath(name string) []string {
+	var path []string
+	// 引号中的子节名原样保留, 其中可以含有点号
+	if i := strings.Index(name, "\""); i != -1 && strings.HasSuffix(name, "\"") && i < len(name)-1 {
+		sub := name[i+1 : len(name)-1]
+		path = sectionPath(strings.TrimSpace(name[:i]))
+		return append(path, sub)
+	}
+	for _, part := range strings.Split(name, ".") {
+		path = append(path, strings.TrimSpace(part))
+	}
+	return path
+}
+
+// sectionIndex 在结构体类型中按 ini tag 逐级查找节的路径, 返回每一级字段的下标
+// 中间的每一级都必须是结构体或结构体指针
+// fold 为 true 时忽略大小写
+func sectionIndex(t reflect.Type, path []string, fold bool) ([]int, bool) {
+	index := make([]int, 0, len(path))
+	for _, name := range path {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, false
+		}
+		fp, ok := planFor(t).section(name, fold)
+		if !ok {
+			return nil, false
+		}
+		index = append(index, fp.index)
+		t = fp.typ
+	}
+	return index, true
+}
+
+// tagPath 返回 sectionIndex 找到的每一级字段的 ini tag
+func tagPath(t reflect.Type, index []int) []string {
+	path := make([]string, 0, len(index))
+	for _, i := range index {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		field := t.Field(i)
+		path = append(path, field.Tag.Get("ini"))
+		t = field.Type
+	}
+	return path
+}
+
+// matchName 判断 ini tag 和文件中的节名或键名是否匹配, fold 为 true 时忽略大小写
+func matchName(tag, name string, fold bool) bool {
+	if len(tag) == 0 {
+		return false
+	}
+	if fold {
+		return strings.EqualFold(tag, name)
+	}
+	return tag == name
+}
+
+// fieldByIndexAlloc 和 reflect.Value.FieldByIndex 一样, 但会为 nil 的结构体指针分配内存并设置默认值
+// 最后一级是结构体指针时返回它指向的结构体
+func fieldByIndexAlloc(v reflect.Value, index []int) (reflect.Value, error) {
+	for _, i := range index {
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		v = v.Field(i)
+		if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct && v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+			if err := applyDefaults(v.Elem()); err != nil {
+				return v, err
+			}
+		}
+	}
+	if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct {
+		v = v.Elem()
+	}
+	return v, nil
+}
+
+// 作为一个值而不是一个节来解析的类型
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType = reflect.TypeOf(time.Time{})
+	urlType = reflect.TypeOf(url.URL{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// isValueType 判断结构体(或其指针)类型是否作为一个值解析, 如 time.Time, *url.URL 和实现了 encoding.TextUnmarshaler 的类型
+func isValueType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == timeType || t == urlType || reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// timeLayout 返回 time.Time 字段的 layout tag, 默认为 RFC3339
+func timeLayout(tag reflect.StructTag) string {
+	if layout, ok := tag.Lookup("layout"); ok {
+		return layout
+	}
+	return time.RFC3339
+}
+
+// setValue 把 ini 中的字符串赋给字段, 值格式不对或超出范围时返回错误, 不支持的类型跳过
+// tag 是字段的 struct tag, time.Time 字段从中读取 layout
+func setValue(fieldObj reflect.Value, value string, tag reflect.StructTag) error {
+	// 1. 需要特殊处理的类型
+	switch fieldObj.Type() {
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		fieldObj.SetInt(int64(d))
+		return nil
+	case timeType:
+		tm, err := time.Parse(timeLayout(tag), value)
+		if err != nil {
+			return err
+		}
+		fieldObj.Set(reflect.ValueOf(tm))
+		return nil
+	case urlType:
+		u, err := url.Parse(value)
+		if err != nil {
+			return err
+		}
+		fieldObj.Set(reflect.ValueOf(*u))
+		return nil
+	}
+	// 2. 指针字段分配一个新值, 文件中没有这个键时保持 nil, 可以和零值区分开
+	if fieldObj.Kind() == reflect.Ptr {
+		elem := reflect.New(fieldObj.Type().Elem())
+		if err := setValue(elem.Elem(), value, tag); err != nil {
+			return err
+		}
+		fieldObj.Set(elem)
+		return nil
+	}
+	// 3. 自己实现了 encoding.TextUnmarshaler 的类型, 如 net.IP
+	if fieldObj.CanAddr() && fieldObj.Addr().Type().Implements(textUnmarshalerType) {
+		return fieldObj.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	// 4. 基本类型, 按字段本身的位数检查范围
+	switch fieldObj.Kind() {
+	case reflect.String:
+		fieldObj.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		valueInt, err := strconv.ParseInt(value, 10, fieldObj.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fieldObj.SetInt(valueInt)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		valueUint, err := strconv.ParseUint(value, 10, fieldObj.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fieldObj.SetUint(valueUint)
+	case reflect.Bool:
+		valueBool, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fieldObj.SetBool(valueBool)
+	case reflect.Float32, reflect.Float64:
+		valueFloat, err := strconv.ParseFloat(value, fieldObj.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fieldObj.SetFloat(valueFloat)
+	case reflect.Slice:
+		// 逗号分隔的多个值, 空值对应空切片
+		fieldObj.Set(reflect.Zero(fieldObj.Type()))
+		return appendSlice(fieldObj, value, tag)
+	}
+	return nil
+}
+
+// appendSlice 把逗号分隔的值逐个转换后追加到切片字段
+func appendSlice(fieldObj reflect.Value, value string, tag reflect.StructTag) error {
+	if len(value) == 0 {
+		return nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		if err := appendItem(fieldObj, strings.TrimSpace(item), tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendItem 把一个元素转换后追加到切片字段
+func appendItem(fieldObj reflect.Value, item string, tag reflect.StructTag) error {
+	elem := reflect.New(fieldObj.Type().Elem()).Elem()
+	if elem.Kind() == reflect.Slice {
+		return fmt.Errorf("unsupported type %s", fieldObj.Type())
+	}
+	if err := setValue(elem, item, tag); err != nil {
+		return err
+	}
+	fieldObj.Set(reflect.Append(fieldObj, elem))
+	return nil
+}
+
+// repeatedKeys 返回节中出现不止一次的键, 键名经过 foldName 处理
+func repeatedKeys(sec *Section, fold bool) map[string]bool {
+	repeated := make(map[string]bool)
+	for _, k := range duplicateKeys(sec, fold) {
+		repeated[foldName(k.Name, fold)] = true
+	}
+	return repeated
+}
+
+// setMap 把整个节的键值对放进 map 字段, map 的键必须是字符串, 值通过 ip 展开
+func setMap(fieldObj reflect.Value, sec *Section, ip *interpolator) error {
+	mapType := fieldObj.Type()
+	if mapType.Key().Kind() != reflect.String {
+		return fmt.Errorf("section [%s]: map key should be a string", sec.Name)
+	}
+	if fieldObj.IsNil() {
+		fieldObj.Set(reflect.MakeMap(mapType))
+	}
+	for _, k := range sec.Keys() {
+		value, err := ip.resolve(k)
+		if err != nil {
+			return err
+		}
+		elem := reflect.New(mapType.Elem()).Elem()
+		if err := setValue(elem, value, ""); err != nil {
+			return keyError(sec, k, err)
+		}
+		fieldObj.SetMapIndex(reflect.ValueOf(k.Name).Convert(mapType.Key()), elem)
+	}
+	return nil
+}
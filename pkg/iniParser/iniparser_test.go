@@ -0,0 +1,157 @@
+package iniparser
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// loadString 把 ini 内容写进临时文件再用 LoadIni 读取
+func loadString(t *testing.T, content string, data interface{}) error {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), "config.ini")
+	if err := ioutil.WriteFile(fileName, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadIni(fileName, data)
+}
+
+// TestLoadIniSlice 逗号分隔和重复的键都能读进切片, 整个节能读进 map
+func TestLoadIniSlice(t *testing.T) {
+	type redis struct {
+		Hosts []string `ini:"hosts"`
+		Ports []int `ini:"ports"`
+		Flags []bool `ini:"flags"`
+	}
+	type config struct {
+		Redis redis `ini:"redis"`
+		Options map[string]int `ini:"options"`
+	}
+	var cfg config
+	err := loadString(t, "[redis]\nhosts=a, b\nports=6379\nports=6380\nflags=\n[options]\nsize=10\nretry=3\n", &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := config{
+		Redis: redis{Hosts: []string{"a", "b"}, Ports: []int{6379, 6380}},
+		Options: map[string]int{"size": 10, "retry": 3},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got  %#v\nwant %#v", cfg, want)
+	}
+	// 重复的键每行是一个元素, 不再按逗号拆分
+	bad := map[string]string{
+		"repeated key with a comma": "[redis]\nports=6379,6380\nports=6381\n",
+		"bad bool item": "[redis]\nflags=true,x\n",
+		"bad map value": "[options]\nsize=x\n",
+	}
+	for name, content := range bad {
+		if err = loadString(t, content, &config{}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// TestLoadIniNested 点号和引号两种写法的子节都能读进嵌套结构体
+func TestLoadIniNested(t *testing.T) {
+	type replica struct {
+		Address string `ini:"address"`
+	}
+	type mysql struct {
+		Port int `ini:"port"`
+		Replica *replica `ini:"replica"`
+		Backup replica `ini:"backup.v2"`
+	}
+	type config struct {
+		MySQL mysql `ini:"mysql"`
+	}
+	var cfg config
+	err := loadString(t, "[mysql]\nport=3306\n[mysql.replica]\naddress=10.0.0.2\n[mysql \"backup.v2\"]\naddress=10.0.0.3\n", &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MySQL.Port != 3306 || cfg.MySQL.Replica == nil || cfg.MySQL.Replica.Address != "10.0.0.2" || cfg.MySQL.Backup.Address != "10.0.0.3" {
+		t.Errorf("got %#v", cfg.MySQL)
+	}
+}
+
+// TestLoadIniDefaults 没写的键用 default tag 的值, 缺少 required 的键时一次列出全部
+func TestLoadIniDefaults(t *testing.T) {
+	type mysql struct {
+		Address string `ini:"address" default:"127.0.0.1"`
+		Port int `ini:"port" default:"3306"`
+		Username string `ini:"username" required:"true"`
+		Password string `ini:"password" required:"true"`
+	}
+	type config struct {
+		MySQL mysql `ini:"mysql"`
+	}
+	var cfg config
+	if err := loadString(t, "[mysql]\nport=3307\nusername=root\npassword=pw\n", &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if want := (mysql{Address: "127.0.0.1", Port: 3307, Username: "root", Password: "pw"}); cfg.MySQL != want {
+		t.Errorf("got %#v, want %#v", cfg.MySQL, want)
+	}
+	err := loadString(t, "[mysql]\nport=3307\n", &config{})
+	if err == nil || !strings.Contains(err.Error(), "[mysql] username") || !strings.Contains(err.Error(), "[mysql] password") {
+		t.Errorf("expected both missing keys in the error, got %v", err)
+	}
+}
+
+// TestGlobalKeys 第一个节之前的键对应顶层字段, 找不到字段时不论是否严格模式都报告
+func TestGlobalKeys(t *testing.T) {
+	type mysql struct {
+		Address string `ini:"address"`
+	}
+	type config struct {
+		Name string `ini:"name"`
+		MySQL mysql `ini:"mysql"`
+	}
+	var cfg config
+	err := Unmarshal([]byte("name=x\n[mysql]\naddress=db\n"), &cfg)
+	if err != nil || cfg.Name != "x" {
+		t.Fatalf("got %q, %v, want name x", cfg.Name, err)
+	}
+	for _, opts := range []Options{{}, {Strict: true}} {
+		err = Unmarshal([]byte("nmae=x\n[mysql]\naddress=db\n"), &config{}, opts)
+		var pe *ParseError
+		if !errors.Is(err, ErrUnknownKey) || !errors.As(err, &pe) || pe.Line != 1 {
+			t.Errorf("strict=%v: got %v, want unknown key on line 1", opts.Strict, err)
+		}
+	}
+	// 非严格模式下节中未知的键仍然忽略
+	if err = Unmarshal([]byte("[mysql]\naddress=db\nfoo=1\n"), &config{}); err != nil {
+		t.Errorf("unknown key in a section: %v", err)
+	}
+	if err = Unmarshal([]byte("nmae=x\n"), &config{}, Options{AllowUnknownKeys: true}); err != nil {
+		t.Errorf("AllowUnknownKeys: %v", err)
+	}
+	if err = Unmarshal([]byte("mysql=x\n"), &config{}); err == nil {
+		t.Error("a global key naming a section should be an error")
+	}
+}
+
+// TestDefaultSection [DEFAULT] 中的键补给出现过但没有写这些键的节
+func TestDefaultSection(t *testing.T) {
+	type server struct {
+		Host string `ini:"host"`
+		Timeout int `ini:"timeout"`
+	}
+	type config struct {
+		MySQL server `ini:"mysql"`
+		Redis server `ini:"redis"`
+		Cache *server `ini:"cache"`
+	}
+	content := "[DEFAULT]\ntimeout=5\nhost=localhost\nunused=${INI_TEST_UNSET}\n[mysql]\nhost=db\n[redis]\ntimeout=1\n"
+	var cfg config
+	if err := Unmarshal([]byte(content), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MySQL != (server{"db", 5}) || cfg.Redis != (server{"localhost", 1}) || cfg.Cache != nil {
+		t.Errorf("got %+v %+v %+v", cfg.MySQL, cfg.Redis, cfg.Cache)
+	}
+}
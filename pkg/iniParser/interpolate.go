@@ -0,0 +1,197 @@
+package iniparser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 值中的变量展开, LoadIni 给字段赋值时对用到的值处理一次
+//   ${MYSQL_PASSWORD}        环境变量, 未设置时报错
+//   ${MYSQL_PASSWORD:-root}  环境变量, 未设置或为空时使用默认值, 默认值中也可以引用
+//   %(mysql.address)s        引用其他节的键, 只写 %(address)s 时引用本节或 [DEFAULT] 的键
+//   $$ 和 %%                 表示字面的 $ 和 %
+// 引用形成环时报错
+
+// interpolator 展开整个文档中的值
+type interpolator struct {
+	// keys 所有键, 用 sectionKey 生成的名字索引, 同名的以最后出现的为准
+	keys map[string]*Key
+	// paths 每个键所在节的路径, sections 每个键所在的节
+	paths map[*Key][]string
+	sections map[*Key]*Section
+	// values 已经展开过的值
+	values map[*Key]string
+	// stack 正在展开的引用链, 用来检测环
+	stack []string
+	lookupEnv func(string) (string, bool)
+}
+
+// escaper 把 $ 和 % 写成 $$ 和 %%
+var escaper = strings.NewReplacer("$", "$$", "%", "%%")
+
+// EscapeValue 返回字面值在文件中的写法, 读回来展开后仍是原来的值, 用于写出结构体中的值
+func EscapeValue(s string) string {
+	if !strings.ContainsAny(s, "$%") {
+		return s
+	}
+	return escaper.Replace(s)
+}
+
+// newInterpolator 为文档建立键的索引, 值在第一次 resolve 时才展开
+// 只有真正用到的键才会展开, 没有对应字段的键里引用了未设置的环境变量也不会报错
+func newInterpolator(doc *Document) *interpolator {
+	ip := &interpolator{
+		keys: make(map[string]*Key),
+		paths: make(map[*Key][]string),
+		sections: make(map[*Key]*Section),
+		values: make(map[*Key]string),
+		lookupEnv: os.LookupEnv,
+	}
+	for _, sec := range doc.Sections() {
+		var path []string
+		if len(sec.Name) > 0 {
+			path = sectionPath(sec.Name)
+		}
+		for _, k := range sec.Keys() {
+			ip.keys[sectionKey(path, k.Name)] = k
+			ip.paths[k] = path
+			ip.sections[k] = sec
+		}
+	}
+	return ip
+}
+
+// resolve 返回键展开后的值
+func (ip *interpolator) resolve(k *Key) (string, error) {
+	if value, ok := ip.values[k]; ok {
+		return value, nil
+	}
+	name := ip.name(k)
+	for i, s := range ip.stack {
+		if s == name {
+			chain := append(ip.stack[i:len(ip.stack):len(ip.stack)], name)
+			return "", ip.error(k, fmt.Errorf("reference cycle - %s", strings.Join(chain, " -> ")))
+		}
+	}
+	if k.literal {
+		ip.values[k] = k.Value
+		return k.Value, nil
+	}
+	ip.stack = append(ip.stack, name)
+	value, err := ip.expand(k.Value, k)
+	ip.stack = ip.stack[:len(ip.stack)-1]
+	if err != nil {
+		return "", err
+	}
+	ip.values[k] = value
+	return value, nil
+}
+
+// expand 展开 s 中的环境变量和引用, k 是 s 所属的键, 用于报错和查找本节的键
+func (ip *interpolator) expand(s string, k *Key) (string, error) {
+	if !strings.ContainsAny(s, "$%") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c != '$' && c != '%') || i+1 >= len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		next := s[i+1]
+		switch {
+		case next == c:
+			// $$ 或 %%
+			b.WriteByte(c)
+			i++
+		case c == '$' && next == '{':
+			end := closingBrace(s[i+2:])
+			if end == -1 {
+				return "", ip.error(k, errors.New("unterminated \"${\""))
+			}
+			value, err := ip.env(s[i+2:i+2+end], k)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i += end + 2
+		case c == '%' && next == '(':
+			end := strings.Index(s[i:], ")s")
+			if end == -1 {
+				return "", ip.error(k, errors.New("unterminated \"%(\""))
+			}
+			value, err := ip.reference(s[i+2:i+end], k)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i += end + 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// closingBrace 返回和 ${ 配对的 } 在 s 中的位置, 默认值中可以嵌套 ${...}
+func closingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// name 返回键的完整名字, 用于检测环
+func (ip *interpolator) name(k *Key) string {
+	return sectionKey(ip.paths[k], k.Name)
+}
+
+// error 生成键 k 展开失败的 ParseError
+func (ip *interpolator) error(k *Key, err error) error {
+	return keyError(ip.sections[k], k, err)
+}
+
+// env 展开 ${VAR} 或 ${VAR:-default}
+func (ip *interpolator) env(expr string, k *Key) (string, error) {
+	name, def, hasDefault := expr, "", false
+	if i := strings.Index(expr, ":-"); i != -1 {
+		name, def, hasDefault = expr[:i], expr[i+2:], true
+	}
+	value, ok := ip.lookupEnv(name)
+	if hasDefault && len(value) == 0 {
+		return ip.expand(def, k)
+	}
+	if !ok {
+		return "", ip.error(k, fmt.Errorf("environment variable %s is not set", name))
+	}
+	return value, nil
+}
+
+// reference 展开 %(section.key)s 或 %(key)s
+func (ip *interpolator) reference(ref string, k *Key) (string, error) {
+	name := ref
+	if !strings.Contains(ref, ".") {
+		name = sectionKey(ip.paths[k], ref)
+	}
+	target, ok := ip.keys[name]
+	if !ok && !strings.Contains(ref, ".") {
+		// 和 configparser 一样, 本节没有的键再到 [DEFAULT] 中找
+		target, ok = ip.keys[sectionKey([]string{defaultSection}, ref)]
+	}
+	if !ok {
+		return "", ip.error(k, fmt.Errorf("undefined reference %%(%s)s", ref))
+	}
+	return ip.resolve(target)
+}
@@ -0,0 +1,65 @@
+package iniparser
+
+import (
+	"strings"
+	"testing"
+)
+
+type interpolateConfig struct {
+	MySQL struct {
+		Address string `ini:"address"`
+		Password string `ini:"password"`
+		DSN string `ini:"dsn"`
+	} `ini:"mysql"`
+	Options map[string]string `ini:"options"`
+}
+
+// TestLoadIniInterpolate 环境变量, 默认值, 引用和转义
+func TestLoadIniInterpolate(t *testing.T) {
+	t.Setenv("INI_TEST_PASSWORD", "s3cret")
+	t.Setenv("INI_TEST_EMPTY", "")
+	content := `[mysql]
+address=${INI_TEST_EMPTY:-${INI_TEST_HOST:-127.0.0.1}}
+password=${INI_TEST_PASSWORD}$$%%
+dsn=root:%(password)s@%(mysql.address)s
+[options]
+prefix=%(mysql.address)s:
+`
+	var cfg interpolateConfig
+	if err := loadString(t, content, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MySQL.Address != "127.0.0.1" || cfg.MySQL.Password != "s3cret$%" || cfg.MySQL.DSN != "root:s3cret$%@127.0.0.1" {
+		t.Errorf("got %+v", cfg.MySQL)
+	}
+	if cfg.Options["prefix"] != "127.0.0.1:" {
+		t.Errorf("map value got %q", cfg.Options["prefix"])
+	}
+}
+
+// TestLoadIniInterpolateErrors 只有赋给字段的键展开出错时才报错
+func TestLoadIniInterpolateErrors(t *testing.T) {
+	cases := map[string]string{
+		"unset variable": "[mysql]\npassword=${INI_TEST_UNSET}\n",
+		"unterminated": "[mysql]\npassword=${INI_TEST_UNSET\n",
+		"undefined reference": "[mysql]\npassword=%(nope)s\n",
+		"cycle": "[mysql]\naddress=%(dsn)s\ndsn=%(address)s\n",
+		"map value": "[options]\nprefix=${INI_TEST_UNSET}\n",
+		"reference to an unmapped key": "[mysql]\npassword=%(other.x)s\n[other]\nx=${INI_TEST_UNSET}\n",
+	}
+	for name, content := range cases {
+		if err := loadString(t, content, &interpolateConfig{}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	err := loadString(t, "[mysql]\naddress=%(dsn)s\ndsn=%(address)s\n", &interpolateConfig{})
+	if err == nil || !strings.Contains(err.Error(), "mysql.address -> mysql.dsn -> mysql.address") {
+		t.Errorf("cycle error should show the chain, got %v", err)
+	}
+	// 没有对应字段的节和键不展开
+	content := "[mysql]\naddress=db.local\nunused=${INI_TEST_UNSET}\n[other]\nx=${INI_TEST_UNSET}\n"
+	var cfg interpolateConfig
+	if err = loadString(t, content, &cfg); err != nil {
+		t.Errorf("unmapped keys should not be expanded: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package iniparser
+
+import (
+	"strings"
+)
+
+// 用 section.key 这样的路径读写文档, 全局的键只写 key, 嵌套的节写成 mysql.replica.port
+// [mysql.replica] 和 [mysql "replica"] 都对应 mysql.replica, 供 initool 的 get 和 set 使用
+
+// LoadDocument 读取本地文件和 include 的文件, 按 opts.Profile 选择节并展开继承
+// 返回的文档只用于查询, 不能写回, 格式按扩展名或 opts.Format 选择
+func LoadDocument(fileName string, opts ...Options) (*Document, error) {
+	o := mergeOptions(opts)
+	doc, err := readDocument(fileName, o.Format)
+	if err != nil {
+		return nil, err
+	}
+	return resolveSections(doc, o.Profile)
+}
+
+// Lookup 返回 path 对应的键展开变量后的值, 和 LoadIni 一样以最后出现的为准
+// 只展开这一个键, 其他键引用的环境变量没有设置也不影响, 键不存在时返回 false
+func (doc *Document) Lookup(path string) (string, bool, error) {
+	section, key := splitKeyPath(path)
+	k := findKey(doc, section, key)
+	if k == nil {
+		return "", false, nil
+	}
+	value, err := newInterpolator(doc).resolve(k)
+	return value, true, err
+}
+
+// SetPath 修改 path 对应的键, 没有时加到最后一个对应的节中, 节也没有时在文档末尾新建
+// value 按原样写入, 字面的 $ 和 % 先用 EscapeValue 处理
+func (doc *Document) SetPath(path, value string) {
+	section, key := splitKeyPath(path)
+	if k := findKey(doc, section, key); k != nil {
+		k.Value = value
+		return
+	}
+	sec := findSection(doc, section)
+	if sec == nil {
+		sec = doc.AddSection(section)
+	}
+	sec.SetKey(key, value)
+}
+
+// splitKeyPath 把 section.key 拆成节名和键名, 没有点号时是全局的键
+func splitKeyPath(s string) (string, string) {
+	i := strings.LastIndex(s, ".")
+	if i == -1 {
+		return "", s
+	}
+	return s[:i], s[i+1:]
+}
+
+// matchSection 判断节是否是 section 指定的节, [mysql.replica] 和 [mysql "replica"] 都匹配 mysql.replica
+func matchSection(sec *Section, section string) bool {
+	if len(sec.Name) == 0 || len(section) == 0 {
+		return sec.Name == section
+	}
+	return strings.Join(sectionPath(sec.Name), ".") == section
+}
+
+// findSection 返回最后一个匹配 section 的节, 没有时返回 nil
+func findSection(doc *Document, section string) *Section {
+	for i := len(doc.sections) - 1; i >= 0; i-- {
+		if matchSection(doc.sections[i], section) {
+			return doc.sections[i]
+		}
+	}
+	return nil
+}
+
+// findKey 和 LoadIni 一样, 返回最后出现的 section 节中的 key, 没有时返回 nil
+func findKey(doc *Document, section, key string) *Key {
+	for i := len(doc.sections) - 1; i >= 0; i-- {
+		if !matchSection(doc.sections[i], section) {
+			continue
+		}
+		if k := doc.sections[i].Key(key); k != nil {
+			return k
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package iniparser
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestDocumentLookup 按 section.key 取展开后的值, 以最后出现的为准, 只展开要取的键
+func TestDocumentLookup(t *testing.T) {
+	t.Setenv("INI_TEST_PORT", "3307")
+	dir := writeFiles(t, map[string]string{
+		"base.ini": "[mysql]\naddress=db\nport=3306\n",
+		"config.ini": "include=base.ini\nname=app\n\n[mysql]\nport=${INI_TEST_PORT}\nbroken=${INI_TEST_NOT_SET}\n\n" +
+			"[mysql.replica]\naddress=%(mysql.address)s-replica\n\n[mysql.replica@prod : mysql.replica]\naddress=prod-replica\n",
+	})
+	fileName := filepath.Join(dir, "config.ini")
+	doc, err := LoadDocument(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"name": "app",
+		"mysql.address": "db",
+		"mysql.port": "3307",
+		"mysql.replica.address": "db-replica",
+	} {
+		value, ok, err := doc.Lookup(path)
+		if err != nil || !ok || value != want {
+			t.Errorf("Lookup(%s) = %q, %v, %v, want %q", path, value, ok, err, want)
+		}
+	}
+	if _, ok, err := doc.Lookup("mysql.user"); ok || err != nil {
+		t.Errorf("Lookup(mysql.user) = %v, %v, want not found", ok, err)
+	}
+	if _, _, err := doc.Lookup("mysql.broken"); err == nil {
+		t.Error("expected an error for an unset environment variable")
+	}
+	prod, err := LoadDocument(fileName, Options{Profile: "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _, _ := prod.Lookup("mysql.replica.address"); value != "prod-replica" {
+		t.Errorf("got %q with the prod profile, want prod-replica", value)
+	}
+}
+
+// TestDocumentSetPath 修改已有的键, 添加键和节, 其余内容不变, [mysql "replica"] 对应 mysql.replica
+func TestDocumentSetPath(t *testing.T) {
+	doc, err := ParseDocument([]byte("name=app\n\n[mysql] ; db\nport=3306 ; default\n\n[mysql \"replica\"]\nport=3307\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.SetPath("mysql.port", "3308")
+	doc.SetPath("mysql.replica.address", "replica")
+	doc.SetPath("name", "app2")
+	doc.SetPath("redis.host", "cache")
+	want := "name=app2\n\n[mysql] ; db\nport=3308 ; default\n\n[mysql \"replica\"]\nport=3307\naddress=replica\n\n[redis]\nhost=cache\n"
+	if got := string(doc.Bytes()); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	flat := map[string]string{
+		"name": "app2",
+		"mysql.port": "3308",
+		"mysql.replica.port": "3307",
+		"mysql.replica.address": "replica",
+		"redis.host": "cache",
+	}
+	if got := doc.Flatten(); !reflect.DeepEqual(got, flat) {
+		t.Errorf("Flatten() = %v, want %v", got, flat)
+	}
+}
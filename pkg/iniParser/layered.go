@@ -0,0 +1,100 @@
+package iniparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 分层加载: 按顺序读取多个文件, 后面的文件逐个键覆盖前面的
+//   LoadLayered(&cfg, "base.ini", "env.ini", "local.ini")
+// 文件开头(第一个 [section] 之前)可以用 include 引入其他文件, 路径相对于当前文件
+//   include = base.ini
+// 被引入的文件先加载, 当前文件中的键覆盖它们, 多个 include 按出现顺序加载
+
+// includeKey 全局节中表示引入其他文件的键
+const includeKey = "include"
+
+// LoadLayered 依次加载 files 并合并后赋给 data, 同一个键以最后出现的为准
+// 需要解析选项时使用 Overlay.Load
+func LoadLayered(data interface{}, files ...string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no config file to load")
+	}
+	var layers []*Document
+	for _, fileName := range files {
+		doc, err := readDocument(fileName, "")
+		if err != nil {
+			return err
+		}
+		layers = append(layers, doc)
+	}
+	return decodeDocument(mergeDocuments(layers...), data, Options{})
+}
+
+// readDocument 读取并解析本地文件, 把 include 的文件按顺序合并在它前面
+// format 是文件的格式, 为空时按扩展名判断, 见 decoderFor
+func readDocument(fileName, format string) (*Document, error) {
+	return readIncludes(osSource{}, fileName, format, nil)
+}
+
+// readIncludes 递归读取 include 的文件, stack 是正在读取的文件链, 用来检测循环引用
+// include 的文件总是按自己的扩展名判断格式, 可以和当前文件的格式不同
+func readIncludes(src fileSource, fileName, format string, stack []string) (*Document, error) {
+	id, err := src.id(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range stack {
+		if f == id {
+			chain := append(stack[i:len(stack):len(stack)], id)
+			return nil, fmt.Errorf("include cycle - %s", strings.Join(chain, " -> "))
+		}
+	}
+	stack = append(stack, id)
+	// 读取文件, 获得字节类型的数据
+	b, err := src.readFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := decoderFor(format, fileName)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decoder.Decode(b)
+	if err != nil {
+		if pe, ok := err.(*ParseError); ok {
+			pe.File = fileName
+		}
+		return nil, err
+	}
+	for _, sec := range doc.Sections() {
+		sec.File = fileName
+	}
+	// include 只在全局节中生效
+	var layers []*Document
+	global := doc.Sections()[0]
+	for _, k := range global.Keys() {
+		if k.Name != includeKey {
+			continue
+		}
+		included, err := readIncludes(src, src.resolve(fileName, k.Value), "", stack)
+		if err != nil {
+			return nil, keyError(global, k, err)
+		}
+		layers = append(layers, included)
+	}
+	if len(layers) == 0 {
+		return doc, nil
+	}
+	return mergeDocuments(append(layers, doc)...), nil
+}
+
+// mergeDocuments 把多个文档的节按顺序拼成一个文档, 解析时后面的键覆盖前面的
+// 合并后的文档只用于解析, 不用于写回
+func mergeDocuments(docs ...*Document) *Document {
+	merged := &Document{}
+	for _, doc := range docs {
+		merged.sections = append(merged.sections, doc.sections...)
+	}
+	return merged
+}
@@ -0,0 +1,61 @@
+package iniparser
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles 在临时目录中写入一组文件, 返回目录
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+type layeredConfig struct {
+	MySQL struct {
+		Address string `ini:"address"`
+		Port int `ini:"port" default:"3306"`
+		Hosts []string `ini:"hosts"`
+	} `ini:"mysql"`
+}
+
+// TestLoadLayered 后面的文件逐个键覆盖前面的, include 的文件先加载
+func TestLoadLayered(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.ini": "[mysql]\naddress=10.0.0.1\nport=3307\nhosts=a\nhosts=b\n",
+		"env.ini": "include = base.ini\n[mysql]\naddress=10.0.0.2\n",
+		"local.ini": "[mysql]\nhosts=c\n",
+	})
+	var cfg layeredConfig
+	err := LoadLayered(&cfg, filepath.Join(dir, "env.ini"), filepath.Join(dir, "local.ini"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := cfg.MySQL
+	if m.Address != "10.0.0.2" || m.Port != 3307 || len(m.Hosts) != 1 || m.Hosts[0] != "c" {
+		t.Errorf("got %+v", m)
+	}
+	if err = LoadLayered(&cfg); err == nil {
+		t.Error("expected an error without files")
+	}
+}
+
+// TestLoadLayeredIncludeCycle 循环 include 时报出整条链
+func TestLoadLayeredIncludeCycle(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.ini": "include = b.ini\n",
+		"b.ini": "include = a.ini\n",
+	})
+	err := LoadIni(filepath.Join(dir, "a.ini"), &layeredConfig{})
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("expected an include cycle error, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package iniparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 值的词法规则, ParseDocument 对 = 之后的内容按下面的规则取值
+//   key = value ; comment    不加引号的值去掉首尾空白, 空白之后的 ; 或 # 开始行内注释
+//   key = a;b#c              紧挨着的 ; 和 # 是值的一部分
+//   key=#fff                 紧跟在 = 之后的 ; 和 # 也是值的一部分
+//   key = "  a;b # c\n"      双引号中可以有 \n \t \" \\ \uXXXX 等转义(和 Go 的字符串相同)
+//   key = 'C:\dir'           单引号中的内容原样保留, 没有转义
+//   key = first, \           不加引号的值以 \ 结尾时和下一行拼接, 下一行开头的空白去掉
+//         second
+// 引号之后只能有空白或行内注释
+// SaveIni 和 Document.Set 写出的值在需要时自动加上双引号, 保证读回来的值相同
+
+// bom UTF-8 的字节顺序标记, 解析时去掉, 写回时保留
+const bom = "\ufeff"
+
+// valueToken lexValue 取出的值
+type valueToken struct {
+	value string
+	// start 和 end 是值(包括引号)在行中的起止位置, 出错时 start 是出错的位置
+	start int
+	end int
+	// cont 值以 \ 结尾, 需要和下一行拼接
+	cont bool
+}
+
+// lexValue 从 raw[from:] 中取出一个值, raw 是键所在的行
+func lexValue(raw string, from int) (valueToken, error) {
+	start, _ := trimRange(raw, from, len(raw))
+	if start == len(raw) {
+		return valueToken{start: start, end: start}, nil
+	}
+	switch raw[start] {
+	case '"', '\'':
+		return lexQuoted(raw, start)
+	}
+	// 1. 找到行内注释的开头, 只有原始行中前面是空白(或者是续行的行首)的 ; 和 # 才开始注释
+	// key=#fff 的值是 #fff
+	end := len(raw)
+	for i := start; i < len(raw); i++ {
+		if isComment(raw[i]) && i > 0 && (isSpace(raw[i-1]) || raw[i-1] == '\n') {
+			end = i
+			break
+		}
+	}
+	_, end = trimRange(raw, start, end)
+	tok := valueToken{value: raw[start:end], start: start, end: end}
+	// 2. 没有注释时, 结尾的 \ 表示续行, \ 之前的空白保留
+	if end == len(strings.TrimRight(raw, " \t\r")) && end > start && raw[end-1] == '\\' {
+		tok.value = raw[start : end-1]
+		tok.cont = true
+	}
+	return tok, nil
+}
+
+// lexQuoted 取出从 raw[start] 开始的引号中的值
+func lexQuoted(raw string, start int) (valueToken, error) {
+	value, end, err := scanQuoted(raw, start)
+	if err != nil {
+		return valueToken{start: end}, err
+	}
+	// 引号之后只能是空白或行内注释
+	rest, _ := trimRange(raw, end, len(raw))
+	if rest < len(raw) && !isComment(raw[rest]) {
+		return valueToken{start: rest}, fmt.Errorf("%w: unexpected text after quoted value", ErrSyntax)
+	}
+	return valueToken{value: value, start: start, end: end}, nil
+}
+
+// scanQuoted 解码从 raw[start] 开始的引号中的字符串, 返回值和结束引号之后的位置
+// 出错时返回的位置是出错的地方
+func scanQuoted(raw string, start int) (string, int, error) {
+	quote := raw[start]
+	var b strings.Builder
+	i := start + 1
+	for {
+		if i >= len(raw) || raw[i] == '\r' {
+			return "", start, fmt.Errorf("%w: unterminated string", ErrSyntax)
+		}
+		if raw[i] == quote {
+			return b.String(), i + 1, nil
+		}
+		if quote == '\'' {
+			b.WriteByte(raw[i])
+			i++
+			continue
+		}
+		// 双引号中的转义和 Go 的字符串相同
+		r, multibyte, tail, err := strconv.UnquoteChar(raw[i:], quote)
+		if err != nil {
+			return "", i, fmt.Errorf("%w: invalid escape sequence", ErrSyntax)
+		}
+		if r < utf8.RuneSelf || !multibyte {
+			b.WriteByte(byte(r))
+		} else {
+			b.WriteRune(r)
+		}
+		i = len(raw) - len(tail)
+	}
+}
+
+func isComment(c byte) bool {
+	return c == ';' || c == '#'
+}
+
+// sectionEnd 返回节标题中 ] 的位置, 双引号中的 ] 不算, 没有时返回 -1
+func sectionEnd(line string) int {
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case ']':
+			if !quoted {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// quoteValue 返回写入文件时值的写法, 原样写出会被读成别的值时加上双引号
+func quoteValue(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+	needQuote := isSpace(s[0]) || isSpace(s[len(s)-1]) ||
+		s[0] == '"' || s[0] == '\'' || isComment(s[0]) || s[len(s)-1] == '\\'
+	for i := 0; i < len(s) && !needQuote; i++ {
+		switch {
+		case s[i] < ' ' && s[i] != '\t', s[i] == 0x7f:
+			needQuote = true
+		case isComment(s[i]) && isSpace(s[i-1]):
+			needQuote = true
+		}
+	}
+	if !needQuote {
+		return s
+	}
+	return strconv.Quote(s)
+}
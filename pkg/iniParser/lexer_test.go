@@ -0,0 +1,113 @@
+package iniparser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// lexLine 取出一行中等号之后的值
+func lexLine(raw string) (valueToken, error) {
+	return lexValue(raw, strings.IndexByte(raw, '=')+1)
+}
+
+// TestLexValue 等号之后的值按词法规则取出
+func TestLexValue(t *testing.T) {
+	cases := []struct {
+		raw string
+		value string
+		cont bool
+	}{
+		{"key=value", "value", false},
+		{"key =  spaced value  ", "spaced value", false},
+		{"key=", "", false},
+		{"key = ; comment", "", false},
+		{"key = value ; comment", "value", false},
+		{"key = value\t# comment", "value", false},
+		// 前面不是空白的 ; 和 # 是值的一部分
+		{"key = a;b#c", "a;b#c", false},
+		{"password=#abc;def", "#abc;def", false},
+		{"color=#fff", "#fff", false},
+		{"key=;x", ";x", false},
+		{`key = "  a;b # c\n"  ; comment`, "  a;b # c\n", false},
+		{`key = "tab\tquote\"back\\slash\u00e9"`, "tab\tquote\"back\\slash\u00e9", false},
+		{`key = 'C:\dir\n'`, `C:\dir\n`, false},
+		{`key = "a"#comment`, "a", false},
+		{`key = first, \`, "first, ", true},
+		{"key = first, \\  ", "first, ", true},
+		{`key = not\ continued ; comment \`, `not\ continued`, false},
+		{"key = value\r", "value", false},
+		{"key = \"value\"\r", "value", false},
+	}
+	for _, c := range cases {
+		tok, err := lexLine(c.raw)
+		if err != nil {
+			t.Errorf("%q: %v", c.raw, err)
+			continue
+		}
+		if tok.value != c.value || tok.cont != c.cont {
+			t.Errorf("%q: got %q cont=%v, want %q cont=%v", c.raw, tok.value, tok.cont, c.value, c.cont)
+		}
+	}
+}
+
+// TestLexValueErrors 引号不配对, 转义写错或引号后面还有内容时返回 ErrSyntax 和出错的位置
+func TestLexValueErrors(t *testing.T) {
+	cases := []struct {
+		raw string
+		start int
+	}{
+		{`key = "open`, 6},
+		{`key = 'open`, 6},
+		{`key = "bad \q"`, 11},
+		{`key = "a" b`, 10},
+	}
+	for _, c := range cases {
+		tok, err := lexLine(c.raw)
+		if !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: expected ErrSyntax, got %v", c.raw, err)
+			continue
+		}
+		if tok.start != c.start {
+			t.Errorf("%q: error at %d, want %d", c.raw, tok.start, c.start)
+		}
+	}
+}
+
+// TestParseDocumentValues 续行, BOM, CRLF 和行内注释解析后的值
+func TestParseDocumentValues(t *testing.T) {
+	in := "\ufeff[mysql] ; main db\r\n" +
+		"hosts = a, \\\r\n" +
+		"        b, \\\r\n" +
+		"  c ; the last one\r\n" +
+		"password=#abc;def\r\n" +
+		"name = \"quoted ; value\" # comment\r\n"
+	doc, err := ParseDocument([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"hosts": "a, b, c", "password": "#abc;def", "name": "quoted ; value"}
+	for key, value := range want {
+		if got, _ := doc.Get("mysql", key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+	if k := doc.Section("mysql").Key("name"); k.Line != 6 {
+		t.Errorf("name is on line %d, want 6", k.Line)
+	}
+	if got := doc.String(); got != in {
+		t.Errorf("round trip changed the document\ngot  %q\nwant %q", got, in)
+	}
+}
+
+// TestQuoteValue 写出的值读回来不变
+func TestQuoteValue(t *testing.T) {
+	values := []string{"", "plain", " lead", "trail ", "a ;b", "a\t#b", "#fff", ";x", "a;b", `"q"`, "'q'", `end\`, "line\nbreak", "tab\there", "é"}
+	for _, v := range values {
+		raw := "key = " + quoteValue(v)
+		tok, err := lexLine(raw)
+		if err != nil || tok.cont || tok.value != v {
+			t.Errorf("%q written as %q reads back as %q (cont=%v), %v", v, raw, tok.value, tok.cont, err)
+		}
+	}
+}
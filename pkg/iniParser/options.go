@@ -0,0 +1,91 @@
+package iniparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 解析选项, 默认忽略结构体中没有的节和键, 重复的键以最后一个为准
+//   err := LoadIni("config.ini", &cfg, Options{Strict: true})
+// 严格模式下会报告:
+//   结构体中找不到的节(ErrUnknownSection)和键(ErrUnknownKey)
+//   同一个文件中重复的节(ErrDuplicateSection)
+//   同一个节中重复的键(ErrDuplicateKey), 切片字段允许重复
+// 所有问题一起以 ParseErrors 返回, 每一项都带有行号
+// 第一个节之前的全局键对应结构体顶层的字段, 找不到字段时不论是否严格模式都报告 ErrUnknownKey
+
+var (
+	ErrUnknownSection = errors.New("unknown section")
+	ErrUnknownKey = errors.New("unknown key")
+	ErrDuplicateSection = errors.New("duplicate section")
+	ErrDuplicateKey = errors.New("duplicate key")
+)
+
+// Options 控制解析的严格程度
+type Options struct {
+	// Strict 报告未知和重复的节和键
+	Strict bool
+	// AllowUnknownKeys 忽略结构体中没有的键, 包括全局的键, 未知的节照样报告
+	AllowUnknownKeys bool
+	// AllowDuplicateKeys 严格模式下仍然允许同一个节中重复的键
+	AllowDuplicateKeys bool
+	// CaseInsensitiveKeys 节名和键名与 ini tag 匹配时忽略大小写, 不论是否严格模式
+	CaseInsensitiveKeys bool
+	// Profile 使用的环境, 如 prod 时 [mysql@prod] 代替 [mysql], 见 LoadProfile
+	Profile string
+	// Keys 解密带 secret tag 的字段用的密钥, 为空时从环境变量 INI_SECRET_KEY 读取
+	Keys KeyProvider
+	// Format 配置的格式: ini, json 或 toml, 为空时按扩展名判断, 没有文件名时是 ini
+	Format string
+}
+
+// mergeOptions 取可变参数中的选项, 没有时使用默认选项
+func mergeOptions(opts []Options) Options {
+	if len(opts) == 0 {
+		return Options{}
+	}
+	return opts[len(opts)-1]
+}
+
+// ParseErrors 一次解析中发现的多个问题
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, pe := range e {
+		msgs = append(msgs, pe.Error())
+	}
+	return fmt.Sprintf("%d error(s):\n%s", len(e), strings.Join(msgs, "\n"))
+}
+
+// Unwrap 让 errors.Is 和 errors.As 可以检查其中的每一个错误
+func (e ParseErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, pe := range e {
+		errs = append(errs, pe)
+	}
+	return errs
+}
+
+// duplicateKeys 返回节中和前面的键重名的键, fold 为 true 时忽略大小写
+func duplicateKeys(sec *Section, fold bool) []*Key {
+	var dups []*Key
+	seen := make(map[string]bool)
+	for _, k := range sec.Keys() {
+		name := foldName(k.Name, fold)
+		if seen[name] {
+			dups = append(dups, k)
+		}
+		seen[name] = true
+	}
+	return dups
+}
+
+// foldName fold 为 true 时返回小写的名字, 用于忽略大小写的比较
+func foldName(name string, fold bool) string {
+	if fold {
+		return strings.ToLower(name)
+	}
+	return name
+}
@@ -0,0 +1,132 @@
+package iniparser
+
+import (
+	"errors"
+	"testing"
+)
+
+type strictConfig struct {
+	MySQL struct {
+		Address string `ini:"address"`
+		Port int `ini:"port"`
+		Hosts []string `ini:"hosts"`
+	} `ini:"mysql"`
+	Options map[string]string `ini:"options"`
+}
+
+// strictErrors 用 opts 解析 content, 返回严格模式报告的每个问题
+func strictErrors(t *testing.T, content string, data interface{}, opts Options) ParseErrors {
+	t.Helper()
+	err := Unmarshal([]byte(content), data, opts)
+	if err == nil {
+		return nil
+	}
+	var errs ParseErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ParseErrors, got %v", err)
+	}
+	return errs
+}
+
+// TestStrict 严格模式报告未知和重复的节和键, 每一项带有行号
+func TestStrict(t *testing.T) {
+	content := `[mysql]
+address=db
+port=1
+port=2
+hosts=a
+hosts=b
+timeout=5
+[redis]
+host=cache
+[mysql]
+address=db2
+[options]
+x=1
+x=2
+`
+	// 默认忽略这些问题, 重复的键以最后一个为准
+	var cfg strictConfig
+	if errs := strictErrors(t, content, &cfg, Options{}); errs != nil {
+		t.Fatalf("non-strict mode failed: %v", errs)
+	}
+	if cfg.MySQL.Address != "db2" || cfg.MySQL.Port != 2 || len(cfg.MySQL.Hosts) != 2 || cfg.Options["x"] != "2" {
+		t.Errorf("got %+v", cfg)
+	}
+	errs := strictErrors(t, content, &strictConfig{}, Options{Strict: true})
+	want := []struct {
+		line int
+		err error
+	}{
+		{4, ErrDuplicateKey},
+		{7, ErrUnknownKey},
+		{8, ErrUnknownSection},
+		{10, ErrDuplicateSection},
+		{14, ErrDuplicateKey},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d:\n%v", len(errs), len(want), errs)
+	}
+	for i, w := range want {
+		if errs[i].Line != w.line || !errors.Is(errs[i], w.err) {
+			t.Errorf("error %d = %v, want line %d %v", i, errs[i], w.line, w.err)
+		}
+	}
+	if !errors.Is(ParseErrors(errs), ErrUnknownSection) {
+		t.Error("errors.Is should see every error in ParseErrors")
+	}
+}
+
+// TestStrictAllow AllowUnknownKeys 和 AllowDuplicateKeys 放宽对应的检查, 未知的节照样报告
+func TestStrictAllow(t *testing.T) {
+	content := "[mysql]\nport=1\nport=2\ntimeout=5\n[options]\nx=1\nx=2\n"
+	opts := Options{Strict: true, AllowUnknownKeys: true, AllowDuplicateKeys: true}
+	var cfg strictConfig
+	if errs := strictErrors(t, content, &cfg, opts); errs != nil {
+		t.Errorf("got %v", errs)
+	}
+	if cfg.MySQL.Port != 2 {
+		t.Errorf("the last duplicate key should win, got %d", cfg.MySQL.Port)
+	}
+	errs := strictErrors(t, content+"[redis]\n", &strictConfig{}, opts)
+	if len(errs) != 1 || !errors.Is(errs[0], ErrUnknownSection) {
+		t.Errorf("got %v, want only the unknown section", errs)
+	}
+}
+
+// TestCaseInsensitiveKeys 忽略大小写匹配节名和键名, 只是大小写不同的键也算重复
+func TestCaseInsensitiveKeys(t *testing.T) {
+	content := "[MySQL]\nADDRESS=db\nHosts=a\nhosts=b,c\n"
+	if errs := strictErrors(t, content, &strictConfig{}, Options{Strict: true}); len(errs) != 1 || !errors.Is(errs[0], ErrUnknownSection) {
+		t.Errorf("case sensitive strict mode: got %v", errs)
+	}
+	var cfg strictConfig
+	if errs := strictErrors(t, content, &cfg, Options{Strict: true, CaseInsensitiveKeys: true}); errs != nil {
+		t.Fatalf("got %v", errs)
+	}
+	if cfg.MySQL.Address != "db" || len(cfg.MySQL.Hosts) != 2 || cfg.MySQL.Hosts[1] != "b,c" {
+		t.Errorf("got %+v", cfg.MySQL)
+	}
+	errs := strictErrors(t, "[mysql]\naddress=a\nAddress=b\n", &strictConfig{}, Options{Strict: true, CaseInsensitiveKeys: true})
+	if len(errs) != 1 || !errors.Is(errs[0], ErrDuplicateKey) || errs[0].Line != 3 {
+		t.Errorf("got %v, want a duplicate key on line 3", errs)
+	}
+}
+
+// TestCaseInsensitiveRedisHost RedisConfig 的 tag 是 host, 老的配置文件中写的是 HOST
+func TestCaseInsensitiveRedisHost(t *testing.T) {
+	content := "[redis]\nHOST=10.0.0.1\n"
+	type config struct {
+		Redis RedisConfig `ini:"redis"`
+	}
+	if errs := strictErrors(t, content, &config{}, Options{Strict: true}); len(errs) != 1 || !errors.Is(errs[0], ErrUnknownKey) {
+		t.Errorf("case sensitive strict mode: got %v", errs)
+	}
+	var cfg config
+	if errs := strictErrors(t, content, &cfg, Options{Strict: true, CaseInsensitiveKeys: true}); errs != nil {
+		t.Fatalf("got %v", errs)
+	}
+	if cfg.Redis.Host != "10.0.0.1" {
+		t.Errorf("got host %q", cfg.Redis.Host)
+	}
+}
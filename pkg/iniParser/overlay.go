@@ -0,0 +1,171 @@
+package iniparser
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// 文件之上叠加环境变量和命令行参数, 优先级 flag > env > file > default
+//   o := &Overlay{EnvPrefix: "APP"}
+//   o.BindFlags(flag.CommandLine, &cfg)  // 注册 --mysql.port 等参数
+//   flag.Parse()
+//   err := o.Load(&cfg, "config.ini")    // [mysql] port 可以被 APP_MYSQL_PORT 和 --mysql.port 覆盖
+// 环境变量和参数的值不做变量展开, 切片字段用逗号分隔, 参数也可以重复给出
+
+// Overlay 环境变量和命令行参数的叠加层
+type Overlay struct {
+	// EnvPrefix 环境变量名的前缀, 为空时不读环境变量
+	EnvPrefix string
+	// Options 解析选项, 对文件, 环境变量和参数一样生效
+	Options Options
+	flags []*flagValue
+}
+
+// keyInfo 结构体中一个键的路径和字段信息
+type keyInfo struct {
+	path []string
+	name string
+	field reflect.StructField
+}
+
+// structKeys 递归列出结构体类型中所有带 ini tag 的键, path 是节的路径
+// 嵌套结构体和结构体指针展开成子节, map 类型的节没有固定的键, 不列出
+func structKeys(t reflect.Type, path []string) []keyInfo {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var keys []keyInfo
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("ini")
+		if len(name) == 0 {
+			continue
+		}
+		ft := field.Type
+		switch {
+		case !isSectionType(ft):
+			keys = append(keys, keyInfo{path: path, name: name, field: field})
+		case ft.Kind() == reflect.Map:
+		default:
+			keys = append(keys, structKeys(ft, append(path[:len(path):len(path)], name))...)
+		}
+	}
+	return keys
+}
+
+// envName 由前缀, 节路径和键得到环境变量名, 如 APP_MYSQL_PORT
+func envName(prefix string, path []string, name string) string {
+	parts := append(append([]string{prefix}, path...), name)
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, strings.Join(parts, "_"))
+}
+
+// flagValue 一个键对应的命令行参数, 实现 flag.Value, 记录下参数值, 加载时再赋给结构体
+type flagValue struct {
+	path []string
+	name string
+	isBool bool
+	values []string
+}
+
+func (f *flagValue) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(f.values, ",")
+}
+
+func (f *flagValue) Set(s string) error {
+	f.values = append(f.values, s)
+	return nil
+}
+
+// IsBoolFlag 让 bool 字段可以只写 --redis.test
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// BindFlags 为 data 的每个键在 fs 中注册 --section.key 形式的参数, 需要在 fs.Parse 之前调用
+func (o *Overlay) BindFlags(fs *flag.FlagSet, data interface{}) {
+	for _, ki := range structKeys(reflect.TypeOf(data), nil) {
+		fv := &flagValue{
+			path: ki.path,
+			name: ki.name,
+			isBool: ki.field.Type.Kind() == reflect.Bool || (ki.field.Type.Kind() == reflect.Ptr && ki.field.Type.Elem().Kind() == reflect.Bool),
+		}
+		name := sectionKey(ki.path, ki.name)
+		usage := fmt.Sprintf("override %s from the config file", name)
+		fs.Var(fv, name, usage)
+		if def, ok := ki.field.Tag.Lookup("default"); ok {
+			fs.Lookup(name).DefValue = def
+		}
+		o.flags = append(o.flags, fv)
+	}
+}
+
+// Load 分层加载 files, 再依次叠加环境变量和命令行参数, 最后统一检查必填字段和校验规则
+func (o *Overlay) Load(data interface{}, files ...string) error {
+	var layers []*Document
+	for _, fileName := range files {
+		doc, err := readDocument(fileName, o.Options.Format)
+		if err != nil {
+			return err
+		}
+		layers = append(layers, doc)
+	}
+	// 只对文件中的节按环境选择, 环境变量和参数生成的节名不带 @, 不能被 [mysql@prod] 代替
+	resolved, err := resolveSections(mergeDocuments(layers...), o.Options.Profile)
+	if err != nil {
+		return err
+	}
+	layers = []*Document{resolved}
+	if t := reflect.TypeOf(data); t != nil && t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		layers = append(layers, o.envDocument(t), o.flagDocument())
+	}
+	opts := o.Options
+	opts.Profile = ""
+	return decodeDocument(mergeDocuments(layers...), data, opts)
+}
+
+// envDocument 把设置了的环境变量转成一个文档, 每个键单独一个节, 报错时 File 就是环境变量名
+func (o *Overlay) envDocument(t reflect.Type) *Document {
+	doc := &Document{}
+	if len(o.EnvPrefix) == 0 {
+		return doc
+	}
+	for _, ki := range structKeys(t, nil) {
+		name := envName(o.EnvPrefix, ki.path, ki.name)
+		if value, ok := os.LookupEnv(name); ok {
+			doc.addLiteral("$"+name, ki.path, ki.name, value)
+		}
+	}
+	return doc
+}
+
+// flagDocument 把命令行中给出的参数转成一个文档, 重复给出的参数对应重复的键
+func (o *Overlay) flagDocument() *Document {
+	doc := &Document{}
+	for _, fv := range o.flags {
+		if len(fv.values) > 0 {
+			doc.addLiteral("--"+sectionKey(fv.path, fv.name), fv.path, fv.name, fv.values...)
+		}
+	}
+	return doc
+}
+
+// addLiteral 在文档末尾加一个只含键 name 的节, 多个值对应重复的键, 值不做变量展开, 只用于解析
+func (doc *Document) addLiteral(file string, path []string, name string, values ...string) {
+	sec := &Section{Name: strings.Join(path, "."), File: file}
+	for _, value := range values {
+		sec.keys = append(sec.keys, &Key{Name: name, Value: value, literal: true})
+	}
+	doc.sections = append(doc.sections, sec)
+}
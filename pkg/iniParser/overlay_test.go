@@ -0,0 +1,110 @@
+package iniparser
+
+import (
+	"flag"
+	"path/filepath"
+	"testing"
+)
+
+type overlayConfig struct {
+	MySQL struct {
+		Address string `ini:"address" default:"127.0.0.1"`
+		Port int `ini:"port"`
+		Password string `ini:"password"`
+		Hosts []string `ini:"hosts"`
+		Debug bool `ini:"debug"`
+	} `ini:"mysql"`
+}
+
+// TestOverlayLoad 优先级 flag > env > file > default, 环境变量和参数的值不做变量展开
+func TestOverlayLoad(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"config.ini": "[mysql]\nport=1\npassword=file\n"})
+	fileName := filepath.Join(dir, "config.ini")
+	tests := []struct {
+		name string
+		env map[string]string
+		args []string
+		port int
+		password string
+	}{
+		{"file", nil, nil, 1, "file"},
+		{"env", map[string]string{"APP_MYSQL_PORT": "9", "APP_MYSQL_PASSWORD": "${HOME}"}, nil, 9, "${HOME}"},
+		{"flag", map[string]string{"APP_MYSQL_PORT": "9"}, []string{"--mysql.port=7", "--mysql.password", "%(x)s"}, 7, "%(x)s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			var cfg overlayConfig
+			o := &Overlay{EnvPrefix: "APP"}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			o.BindFlags(fs, &cfg)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Load(&cfg, fileName); err != nil {
+				t.Fatal(err)
+			}
+			m := cfg.MySQL
+			if m.Port != tt.port || m.Password != tt.password || m.Address != "127.0.0.1" {
+				t.Errorf("got %+v, want port %d and password %q", m, tt.port, tt.password)
+			}
+		})
+	}
+}
+
+// TestOverlayFlags 布尔参数可以不带值, 切片参数可以重复给出
+func TestOverlayFlags(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"config.ini": "[mysql]\nhosts=a,b\n"})
+	var cfg overlayConfig
+	o := &Overlay{}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	o.BindFlags(fs, &cfg)
+	if err := fs.Parse([]string{"--mysql.debug", "--mysql.hosts", "c", "--mysql.hosts", "d"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Load(&cfg, filepath.Join(dir, "config.ini")); err != nil {
+		t.Fatal(err)
+	}
+	m := cfg.MySQL
+	if !m.Debug || len(m.Hosts) != 2 || m.Hosts[0] != "c" || m.Hosts[1] != "d" {
+		t.Errorf("got %+v", m)
+	}
+}
+
+// TestOverlayProfile 环境只对文件中的节生效, 环境变量和参数仍然覆盖 [mysql@prod] 中的键
+func TestOverlayProfile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"config.ini": "[mysql]\naddress=db\nport=1\n\n[mysql@prod : mysql]\nport=2\n"})
+	fileName := filepath.Join(dir, "config.ini")
+	tests := []struct {
+		name string
+		env string
+		args []string
+		want int
+	}{
+		{"file", "", nil, 2},
+		{"env", "9", nil, 9},
+		{"flag", "9", []string{"--mysql.port=7"}, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.env) > 0 {
+				t.Setenv("APP_MYSQL_PORT", tt.env)
+			}
+			var cfg overlayConfig
+			o := &Overlay{EnvPrefix: "APP", Options: Options{Profile: "prod"}}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			o.BindFlags(fs, &cfg)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Load(&cfg, fileName); err != nil {
+				t.Fatal(err)
+			}
+			if cfg.MySQL.Port != tt.want || cfg.MySQL.Address != "db" {
+				t.Errorf("got %+v, want port %d and address db", cfg.MySQL, tt.want)
+			}
+		})
+	}
+}
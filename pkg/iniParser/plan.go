@@ -0,0 +1,101 @@
+package iniparser
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// 解析计划: 每个结构体类型的 ini tag 到字段的映射只计算一次, 缓存在 plans 中
+// 之后每个键和节都是一次 map 查找, 不再逐个字段比较 tag, 多个 goroutine 可以同时使用
+
+// fieldPlan 一个带 ini tag 的字段
+type fieldPlan struct {
+	index int
+	name string
+	tag reflect.StructTag
+	typ reflect.Type
+	// section 字段对应一个节, list 字段是切片, 重复的键追加到其中
+	section bool
+	list bool
+}
+
+// structPlan 一个结构体类型的字段映射, 同名的 tag 以第一个字段为准, 和逐个比较时一样
+type structPlan struct {
+	fields []fieldPlan
+	// keys 和 sections 以 tag 为键, folded 开头的以小写的 tag 为键, 用于忽略大小写
+	keys map[string]*fieldPlan
+	foldedKeys map[string]*fieldPlan
+	sections map[string]*fieldPlan
+	foldedSections map[string]*fieldPlan
+}
+
+// plans reflect.Type 到 *structPlan 的缓存
+var plans sync.Map
+
+// planFor 返回结构体类型 t 的解析计划, 第一次用到时计算
+func planFor(t reflect.Type) *structPlan {
+	if p, ok := plans.Load(t); ok {
+		return p.(*structPlan)
+	}
+	p := &structPlan{
+		keys: make(map[string]*fieldPlan),
+		foldedKeys: make(map[string]*fieldPlan),
+		sections: make(map[string]*fieldPlan),
+		foldedSections: make(map[string]*fieldPlan),
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("ini")
+		if len(name) == 0 {
+			continue
+		}
+		p.fields = append(p.fields, fieldPlan{
+			index: i,
+			name: name,
+			tag: field.Tag,
+			typ: field.Type,
+			section: isSectionType(field.Type),
+			list: field.Type.Kind() == reflect.Slice && !isValueType(field.Type),
+		})
+	}
+	for i := range p.fields {
+		fp := &p.fields[i]
+		addPlan(p.keys, fp.name, fp)
+		addPlan(p.foldedKeys, strings.ToLower(fp.name), fp)
+		if fp.section {
+			addPlan(p.sections, fp.name, fp)
+			addPlan(p.foldedSections, strings.ToLower(fp.name), fp)
+		}
+	}
+	// 并发时可能算了两次, 用先存进去的那个
+	actual, _ := plans.LoadOrStore(t, p)
+	return actual.(*structPlan)
+}
+
+// addPlan 只保留第一个同名的字段
+func addPlan(m map[string]*fieldPlan, name string, fp *fieldPlan) {
+	if _, ok := m[name]; !ok {
+		m[name] = fp
+	}
+}
+
+// key 返回 tag 和键名匹配的字段, fold 为 true 时忽略大小写
+func (p *structPlan) key(name string, fold bool) (*fieldPlan, bool) {
+	if fold {
+		fp, ok := p.foldedKeys[strings.ToLower(name)]
+		return fp, ok
+	}
+	fp, ok := p.keys[name]
+	return fp, ok
+}
+
+// section 返回 tag 和节名匹配的节类型字段
+func (p *structPlan) section(name string, fold bool) (*fieldPlan, bool) {
+	if fold {
+		fp, ok := p.foldedSections[strings.ToLower(name)]
+		return fp, ok
+	}
+	fp, ok := p.sections[name]
+	return fp, ok
+}
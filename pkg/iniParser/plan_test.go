@@ -0,0 +1,164 @@
+package iniparser
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// linearField 缓存之前的查找方式, 逐个字段比较 tag, 用来和解析计划对照
+func linearField(t reflect.Type, name string, fold, section bool) (int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if (!section || isSectionType(field.Type)) && matchName(field.Tag.Get("ini"), name, fold) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// TestPlanMatchesLinear 解析计划和逐个比较的结果一样, 同名的 tag 以第一个字段为准
+func TestPlanMatchesLinear(t *testing.T) {
+	type inner struct {
+		A int `ini:"a"`
+	}
+	type outer struct {
+		Skip int
+		Inner inner `ini:"Inner"`
+		Dup int `ini:"inner"`
+		X int `ini:"x"`
+		Y []string `ini:"X"`
+		M map[string]string `ini:"m"`
+	}
+	typ := reflect.TypeOf(outer{})
+	p := planFor(typ)
+	for _, name := range []string{"Inner", "inner", "INNER", "x", "X", "m", "Skip", "missing"} {
+		for _, fold := range []bool{false, true} {
+			for _, section := range []bool{false, true} {
+				want, wantOK := linearField(typ, name, fold, section)
+				lookup := p.key
+				if section {
+					lookup = p.section
+				}
+				fp, ok := lookup(name, fold)
+				if ok != wantOK || ok && fp.index != want {
+					t.Errorf("%q fold=%v section=%v: got %v %v, want field %d %v", name, fold, section, fp, ok, want, wantOK)
+				}
+			}
+		}
+	}
+	if fp, _ := p.key("X", false); !fp.list {
+		t.Error("a []string field should be a list")
+	}
+	if planFor(typ) != p {
+		t.Error("the plan should be cached")
+	}
+}
+
+// TestPlanConcurrent 多个 goroutine 同时解析同一个类型, 用 -race 运行
+func TestPlanConcurrent(t *testing.T) {
+	data, typ := benchConfig(50, "concurrent")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := Unmarshal(data, reflect.New(typ.Elem()).Interface()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// benchConfig 生成一个 [tenant] 节中有 n 个键的 ini 内容和对应的结构体指针类型
+// prefix 用于生成不同的类型, 各个基准测试的解析计划缓存互不影响
+func benchConfig(n int, prefix string) ([]byte, reflect.Type) {
+	var buf bytes.Buffer
+	buf.WriteString("[tenant]\n")
+	fields := make([]reflect.StructField, n)
+	for i := range fields {
+		name := fmt.Sprintf("%s%d", prefix, i)
+		fields[i] = reflect.StructField{
+			Name: fmt.Sprintf("Key%d", i),
+			Type: reflect.TypeOf(0),
+			Tag: reflect.StructTag(fmt.Sprintf(`ini:"%s"`, name)),
+		}
+		fmt.Fprintf(&buf, "%s = %d\n", name, i)
+	}
+	root := reflect.StructOf([]reflect.StructField{{
+		Name: "Tenant",
+		Type: reflect.StructOf(fields),
+		Tag: `ini:"tenant"`,
+	}})
+	return buf.Bytes(), reflect.PtrTo(root)
+}
+
+func benchmarkUnmarshal(b *testing.B, n int, prefix string, prepare func(t reflect.Type)) {
+	data, t := benchConfig(n, prefix)
+	if err := Unmarshal(data, reflect.New(t.Elem()).Interface()); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if prepare != nil {
+			b.StopTimer()
+			prepare(t.Elem())
+			b.StartTimer()
+		}
+		if err := Unmarshal(data, reflect.New(t.Elem()).Interface()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkUnmarshal 解析计划已经缓存, 即同一个类型反复解析的情况
+func BenchmarkUnmarshal(b *testing.B) {
+	for _, n := range []int{100, 1000, 5000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			benchmarkUnmarshal(b, n, "cached", nil)
+		})
+	}
+}
+
+// BenchmarkUnmarshalUncached 每次解析前删掉这个类型的计划, 计划的计算也算在内
+func BenchmarkUnmarshalUncached(b *testing.B) {
+	for _, n := range []int{100, 1000, 5000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			benchmarkUnmarshal(b, n, "uncached", func(t reflect.Type) {
+				plans.Delete(t)
+				plans.Delete(t.Field(0).Type)
+			})
+		})
+	}
+}
+
+// BenchmarkFieldLookup 为节中的每个键找字段, 解析计划是一次 map 查找, 逐个比较是 O(键数 x 字段数)
+func BenchmarkFieldLookup(b *testing.B) {
+	for _, n := range []int{100, 1000, 5000} {
+		_, t := benchConfig(n, "lookup")
+		tenant := t.Elem().Field(0).Type
+		names := make([]string, n)
+		for i := range names {
+			names[i] = fmt.Sprintf("lookup%d", i)
+		}
+		b.Run(fmt.Sprintf("plan/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				p := planFor(tenant)
+				for _, name := range names {
+					p.key(name, false)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("linear/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, name := range names {
+					linearField(tenant, name, false, false)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package iniparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 节的继承和环境配置
+//   [mysql-prod : mysql]     先继承 [mysql] 的所有键, 再用本节的键覆盖, 父节也可以有自己的父节
+//   [mysql@prod]             prod 环境的 [mysql], LoadProfile 选中 prod 时代替 [mysql]
+//   [mysql@prod : mysql]     两者一起用, prod 环境只写和 [mysql] 不同的键
+// 没有选环境时所有带 @ 的节都被忽略, 选了环境时其他环境的节也被忽略
+// 父节按文件中写的名字查找(可以带 @), 不受环境选择的影响
+
+// LoadProfile 和 LoadIni 一样加载文件, 但使用 profile 环境的节
+//   err := LoadProfile("config.ini", "prod", &cfg)
+func LoadProfile(fileName, profile string, data interface{}, opts ...Options) error {
+	o := mergeOptions(opts)
+	doc, err := readDocument(fileName, o.Format)
+	if err != nil {
+		return err
+	}
+	o.Profile = profile
+	return decodeDocument(doc, data, o)
+}
+
+// splitSectionName 把节标题拆成节名和父节名, 如 "mysql-prod : mysql", 引号中的 : 不算
+// 节名或 : 之后的父节名为空时返回 false
+func splitSectionName(title string) (name, parent string, ok bool) {
+	i := lastUnquoted(title, ':')
+	if i == -1 {
+		return title, "", len(title) > 0
+	}
+	name, parent = strings.TrimSpace(title[:i]), strings.TrimSpace(title[i+1:])
+	return name, parent, len(name) > 0 && len(parent) > 0
+}
+
+// splitProfile 把节名拆成基本的节名和环境名, 如 "mysql@prod"
+func splitProfile(name string) (string, string) {
+	if i := lastUnquoted(name, '@'); i != -1 {
+		return strings.TrimSpace(name[:i]), strings.TrimSpace(name[i+1:])
+	}
+	return name, ""
+}
+
+// lastUnquoted 返回 s 中最后一个不在双引号中的字符 c 的位置, 没有时返回 -1
+func lastUnquoted(s string, c byte) int {
+	pos := -1
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == c && !quoted:
+			pos = i
+		}
+	}
+	return pos
+}
+
+// resolveSections 按环境选择节并展开继承, 返回只用于解析的新文档, 原文档不变
+// 返回的文档中没有带 @ 和父节的节, 用空的 profile 再处理一次结果不变
+// 继承来的键复制一份放在子节前面同名的节中, 解析时子节自己的键覆盖它们
+func resolveSections(doc *Document, profile string) (*Document, error) {
+	// 1. 按文件中写的名字索引所有的节, 同名的节有多个时(如分层加载)按顺序继承
+	byName := make(map[string][]*Section)
+	// selected 选中的环境中有对应节的基本节名, 这些基本节会被环境的节代替
+	selected := make(map[string]bool)
+	for _, sec := range doc.Sections() {
+		byName[sec.Name] = append(byName[sec.Name], sec)
+		if base, p := splitProfile(sec.Name); len(p) > 0 && p == profile {
+			selected[base] = true
+		}
+	}
+	resolved := &Document{crlf: doc.crlf, eofNewline: doc.eofNewline}
+	for _, sec := range doc.Sections() {
+		// 2. 环境选择
+		name, p := splitProfile(sec.Name)
+		if len(p) > 0 && p != profile || len(p) == 0 && selected[name] {
+			continue
+		}
+		if len(p) == 0 && len(sec.Parent) == 0 {
+			resolved.sections = append(resolved.sections, sec)
+			continue
+		}
+		// 3. 先放父节链上的键, 最远的祖先在最前面
+		parents, err := parentChain(sec, byName, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range parents {
+			resolved.sections = append(resolved.sections, inheritSection(parent, name))
+		}
+		// 继承来的键已经放在前面, 清掉 Parent, 再次 resolveSections 时不会重复继承
+		own := *sec
+		own.Name, own.Parent = name, ""
+		resolved.sections = append(resolved.sections, &own)
+	}
+	return resolved, nil
+}
+
+// parentChain 返回 sec 的所有祖先节, 按继承顺序排列, stack 用来检测循环继承
+func parentChain(sec *Section, byName map[string][]*Section, stack []string) ([]*Section, error) {
+	if len(sec.Parent) == 0 {
+		return nil, nil
+	}
+	stack = append(stack, sec.Name)
+	for i, name := range stack {
+		if name == sec.Parent {
+			chain := append(stack[i:len(stack):len(stack)], sec.Parent)
+			return nil, sectionError(sec, fmt.Errorf("inheritance cycle - %s", strings.Join(chain, " -> ")))
+		}
+	}
+	parents, ok := byName[sec.Parent]
+	if !ok {
+		return nil, sectionError(sec, fmt.Errorf("parent section %s not found", sec.Parent))
+	}
+	var chain []*Section
+	for _, parent := range parents {
+		ancestors, err := parentChain(parent, byName, stack)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(append(chain, ancestors...), parent)
+	}
+	return chain, nil
+}
+
+// inheritSection 复制父节 parent 的键, 放到名为 name 的节中
+// 键也要复制, 值中的 %(key)s 按子节展开, 父节自己的值不受影响
+func inheritSection(parent *Section, name string) *Section {
+	sec := &Section{
+		Name: name,
+		File: parent.File,
+		Line: parent.Line,
+		head: parent.head,
+		inherited: true,
+	}
+	for _, k := range parent.keys {
+		copied := *k
+		sec.keys = append(sec.keys, &copied)
+	}
+	return sec
+}
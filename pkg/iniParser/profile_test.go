@@ -0,0 +1,83 @@
+package iniparser
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type profileConfig struct {
+	MySQL struct {
+		Address string `ini:"address"`
+		Port int `ini:"port"`
+		DSN string `ini:"dsn"`
+	} `ini:"mysql"`
+	Replica struct {
+		Address string `ini:"address"`
+		Port int `ini:"port"`
+		DSN string `ini:"dsn"`
+	} `ini:"replica"`
+}
+
+const profileIni = `[mysql]
+address=db
+port=3306
+dsn=%(address)s:%(port)s
+
+[mysql@prod : mysql]
+address=prod-db
+
+[replica : mysql]
+address=replica-db
+`
+
+// TestLoadProfile 选中的环境代替基本的节, 继承来的键中的引用按子节展开
+func TestLoadProfile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"config.ini": profileIni})
+	fileName := filepath.Join(dir, "config.ini")
+	tests := []struct {
+		profile string
+		mysql string
+		replica string
+	}{
+		{"", "db:3306", "replica-db:3306"},
+		{"prod", "prod-db:3306", "replica-db:3306"},
+		{"dev", "db:3306", "replica-db:3306"},
+	}
+	for _, tt := range tests {
+		var cfg profileConfig
+		if err := LoadProfile(fileName, tt.profile, &cfg, Options{Strict: true}); err != nil {
+			t.Errorf("profile %q: %v", tt.profile, err)
+			continue
+		}
+		if cfg.MySQL.DSN != tt.mysql || cfg.Replica.DSN != tt.replica || cfg.Replica.Port != 3306 {
+			t.Errorf("profile %q: got %+v %+v, want dsn %s and %s", tt.profile, cfg.MySQL, cfg.Replica, tt.mysql, tt.replica)
+		}
+	}
+	// 原文档不变, 带 @ 的节和父节名还在
+	doc, err := readDocument(fileName, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sec := doc.Section("mysql@prod"); sec == nil || sec.Parent != "mysql" {
+		t.Errorf("got section %+v, want [mysql@prod : mysql]", sec)
+	}
+}
+
+// TestLoadProfileErrors 父节找不到和循环继承
+func TestLoadProfileErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		want string
+	}{
+		{"[replica : mysql]\nport=1\n", "parent section mysql not found"},
+		{"[a : b]\nport=1\n[b : a]\nport=2\n", "inheritance cycle - a -> b -> a"},
+	}
+	for _, tt := range tests {
+		var cfg profileConfig
+		err := loadString(t, tt.content, &cfg)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: got %v, want %q", tt.content, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package iniparser
+
+import (
+	"reflect"
+	"strings"
+)
+
+// 打印配置时隐藏敏感的值
+//   log.Printf("config: %#v", Redacted(cfg))
+// 带 secret:"true" tag 的字段, 以及字段名或 ini tag 像密码的字段(含 password, passwd, secret,
+// token, 或以 key 结尾)会被替换, 字符串换成 ******, 其他类型换成零值, 空字符串保持为空
+// 不想被隐藏的字段写 secret:"false", 节对应的 map 按键名判断
+
+// RedactedValue 代替敏感字符串的值
+const RedactedValue = "******"
+
+// sensitiveWords 字段名或 ini tag 中含有这些词时视为敏感字段
+var sensitiveWords = []string{"password", "passwd", "secret", "token"}
+
+// Redacted 返回 data 的副本, 其中敏感的字段被隐藏, 原来的值不受影响
+// data 是结构体或结构体指针时返回同样类型的值, 其他类型原样返回
+func Redacted(data interface{}) interface{} {
+	v := reflect.ValueOf(data)
+	if !v.IsValid() {
+		return data
+	}
+	return redactValue(v).Interface()
+}
+
+// redactValue 返回 v 隐藏了敏感字段的副本
+func redactValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return v
+		}
+		copied := reflect.New(v.Type().Elem())
+		copied.Elem().Set(redactValue(v.Elem()))
+		return copied
+	case reflect.Struct:
+		if isValueType(v.Type()) {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			fieldObj := copied.Field(i)
+			if !fieldObj.CanSet() {
+				continue
+			}
+			if isSensitive(field) {
+				fieldObj.Set(redactField(fieldObj))
+				continue
+			}
+			fieldObj.Set(redactValue(fieldObj))
+		}
+		return copied
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			value := iter.Value()
+			if IsSensitiveKey(iter.Key().String()) {
+				value = redactField(value)
+			}
+			copied.SetMapIndex(iter.Key(), value)
+		}
+		return copied
+	}
+	return v
+}
+
+// redactField 返回敏感字段隐藏后的值
+func redactField(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.String {
+		if v.Len() == 0 {
+			return v
+		}
+		return reflect.ValueOf(RedactedValue).Convert(v.Type())
+	}
+	return reflect.Zero(v.Type())
+}
+
+// isSensitive 判断结构体字段是否需要隐藏
+func isSensitive(field reflect.StructField) bool {
+	switch field.Tag.Get("secret") {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if isSectionType(field.Type) {
+		return false
+	}
+	return IsSensitiveKey(field.Name) || IsSensitiveKey(field.Tag.Get("ini"))
+}
+
+// IsSensitiveKey 判断名字是否像密码, 如 Password, api_token, secret_key
+func IsSensitiveKey(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range sensitiveWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, "key")
+}
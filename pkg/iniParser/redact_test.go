@@ -0,0 +1,53 @@
+package iniparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestRedacted 隐藏 secret tag 和名字像密码的字段, 原来的值不变
+func TestRedacted(t *testing.T) {
+	type db struct {
+		Address string `ini:"address"`
+		Pass string `ini:"pass" secret:"true"`
+		Password string `ini:"password"`
+		APIToken string `ini:"api_token"`
+		SecretKey []byte `ini:"secret_key"`
+		Monkey string `ini:"monkey" secret:"false"`
+		Empty string `ini:"empty_password"`
+	}
+	type config struct {
+		DB db `ini:"db"`
+		Backup *db `ini:"backup"`
+		Options map[string]string `ini:"options"`
+	}
+	cfg := config{
+		DB: db{Address: "db", Pass: "p", Password: "pw", APIToken: "t", SecretKey: []byte("k"), Monkey: "m"},
+		Backup: &db{Address: "backup", Password: "pw"},
+		Options: map[string]string{"charset": "utf8", "auth_token": "t"},
+	}
+	got, ok := Redacted(&cfg).(*config)
+	if !ok {
+		t.Fatalf("got %T, want *config", Redacted(&cfg))
+	}
+	want := db{Address: "db", Pass: "******", Password: "******", APIToken: "******", Monkey: "m"}
+	if !reflect.DeepEqual(got.DB, want) {
+		t.Errorf("got %+v, want %+v", got.DB, want)
+	}
+	if got.Backup.Password != "******" || got.Backup.Address != "backup" {
+		t.Errorf("got backup %+v", got.Backup)
+	}
+	if got.Options["auth_token"] != "******" || got.Options["charset"] != "utf8" {
+		t.Errorf("got options %v", got.Options)
+	}
+	// 原来的结构体, 指针和 map 都不受影响
+	if cfg.DB.Password != "pw" || cfg.Backup.Password != "pw" || cfg.Options["auth_token"] != "t" {
+		t.Errorf("Redacted changed the original: %+v %+v %v", cfg.DB, cfg.Backup, cfg.Options)
+	}
+	if v, ok := Redacted(cfg).(config); !ok || v.DB.Password != "******" {
+		t.Errorf("got %#v for a struct value", Redacted(cfg))
+	}
+	if Redacted(nil) != nil || Redacted("password") != "password" {
+		t.Error("values other than structs should be returned unchanged")
+	}
+}
@@ -0,0 +1,235 @@
+package iniparser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// 根据配置结构体的 tag 生成带注释的示例 ini 文件和 Markdown 文档, 避免手写的示例和结构体不一致
+//   Port int `ini:"port" default:"3306" validate:"min=1,max=65535" doc:"MySQL 端口"`
+// 用到的 tag: ini, doc, default, required, validate, secret, layout
+//   initool sample > config.sample.ini
+//   initool docs > CONFIG.md
+
+// schemaSection 一个节的说明, 全局节的 Name 为空
+type schemaSection struct {
+	Name string
+	Doc string
+	Fields []schemaField
+}
+
+// schemaField 一个键的说明
+type schemaField struct {
+	Key string
+	Type string
+	Default string
+	HasDefault bool
+	Required bool
+	Secret bool
+	Validate string
+	Doc string
+}
+
+// buildSchema 按 SaveIni 的顺序收集结构体中的节和键, 全局节总是第一个
+func buildSchema(data interface{}) ([]schemaSection, error) {
+	t := reflect.TypeOf(data)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("input should be a struct")
+	}
+	sections := []schemaSection{{Fields: schemaFields(t)}}
+	return appendSchemaSections(sections, "", t), nil
+}
+
+// appendSchemaSections 把 t 中嵌套结构体和 map 对应的节追加到 sections, prefix 是上一级的节名
+func appendSchemaSections(sections []schemaSection, prefix string, t reflect.Type) []schemaSection {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("ini")
+		if len(name) == 0 || !isSectionType(field.Type) {
+			continue
+		}
+		if len(prefix) > 0 {
+			name = prefix + "." + name
+		}
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		sec := schemaSection{Name: name, Doc: field.Tag.Get("doc")}
+		if ft.Kind() == reflect.Map {
+			// map 的节可以有任意的键
+			sec.Fields = []schemaField{{Key: "*", Type: typeName(ft.Elem(), "")}}
+			sections = append(sections, sec)
+			continue
+		}
+		sec.Fields = schemaFields(ft)
+		sections = appendSchemaSections(append(sections, sec), name, ft)
+	}
+	return sections
+}
+
+// schemaFields 返回结构体中键对应的字段的说明
+func schemaFields(t reflect.Type) []schemaField {
+	var fields []schemaField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("ini")
+		if len(key) == 0 || isSectionType(field.Type) {
+			continue
+		}
+		def, hasDefault := field.Tag.Lookup("default")
+		fields = append(fields, schemaField{
+			Key: key,
+			Type: typeName(field.Type, field.Tag),
+			Default: def,
+			HasDefault: hasDefault,
+			Required: field.Tag.Get("required") == "true",
+			Secret: field.Tag.Get("secret") == "true",
+			Validate: field.Tag.Get("validate"),
+			Doc: field.Tag.Get("doc"),
+		})
+	}
+	return fields
+}
+
+// typeName 返回字段类型在文档中的写法
+func typeName(t reflect.Type, tag reflect.StructTag) string {
+	switch t {
+	case durationType:
+		return "duration"
+	case timeType:
+		return "time (" + timeLayout(tag) + ")"
+	case urlType:
+		return "url"
+	}
+	switch {
+	case t.Kind() == reflect.Ptr:
+		return typeName(t.Elem(), tag)
+	case isValueType(t):
+		return t.String()
+	case t.Kind() == reflect.Slice:
+		return "list of " + typeName(t.Elem(), tag)
+	}
+	return t.Kind().String()
+}
+
+// notes 返回键的类型和约束, 用于示例文件的注释
+func (f schemaField) notes() string {
+	notes := []string{f.Type}
+	if f.Required {
+		notes = append(notes, "required")
+	}
+	if f.HasDefault {
+		notes = append(notes, "default: "+f.Default)
+	}
+	if len(f.Validate) > 0 {
+		notes = append(notes, "validate: "+f.Validate)
+	}
+	if f.Secret {
+		notes = append(notes, "secret, may be "+secretPrefix+"...")
+	}
+	return strings.Join(notes, ", ")
+}
+
+// WriteSample 把 data 的结构写成带注释的示例 ini 文件
+// 每个键上方是说明和约束, 有默认值的键写默认值, 必填的键留空, 其余的键注释掉
+func WriteSample(w io.Writer, data interface{}) error {
+	sections, err := buildSchema(data)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for i, sec := range sections {
+		if i == 0 && len(sec.Fields) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		if len(sec.Doc) > 0 {
+			fmt.Fprintf(&b, "; %s\n", sec.Doc)
+		}
+		if len(sec.Name) > 0 {
+			fmt.Fprintf(&b, "[%s]\n", sec.Name)
+		}
+		for j, f := range sec.Fields {
+			if j > 0 {
+				b.WriteString("\n")
+			}
+			if f.Key == "*" {
+				fmt.Fprintf(&b, "; any key, value type: %s\n", f.Type)
+				continue
+			}
+			if len(f.Doc) > 0 {
+				fmt.Fprintf(&b, "; %s\n", f.Doc)
+			}
+			fmt.Fprintf(&b, "; %s\n", f.notes())
+			line := f.Key + "=" + quoteValue(EscapeValue(f.Default))
+			if !f.HasDefault && !f.Required {
+				line = ";" + line
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown 把 data 的结构写成 Markdown 表格, 每个节一个表
+func WriteMarkdown(w io.Writer, data interface{}) error {
+	sections, err := buildSchema(data)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for i, sec := range sections {
+		if i == 0 && len(sec.Fields) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		if len(sec.Name) == 0 {
+			b.WriteString("## Global keys\n\n")
+		} else {
+			fmt.Fprintf(&b, "## [%s]\n\n", sec.Name)
+		}
+		if len(sec.Doc) > 0 {
+			fmt.Fprintf(&b, "%s\n\n", markdownCell(sec.Doc))
+		}
+		b.WriteString("| Key | Type | Default | Required | Validation | Description |\n")
+		b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+		for _, f := range sec.Fields {
+			def, required, doc := "", "", f.Doc
+			if f.HasDefault {
+				def = "`" + f.Default + "`"
+			}
+			if f.Required {
+				required = "yes"
+			}
+			if f.Secret {
+				doc = strings.TrimSpace(doc + " (secret, may be encrypted)")
+			}
+			validate := ""
+			if len(f.Validate) > 0 {
+				validate = "`" + f.Validate + "`"
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s | %s |\n",
+				f.Key, markdownCell(f.Type), markdownCell(def), required, markdownCell(validate), markdownCell(doc))
+		}
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell 转义表格单元格中的 | 和换行
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,75 @@
+package iniparser
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type schemaConfig struct {
+	Name string `ini:"name" default:"app" doc:"application name"`
+	MySQL struct {
+		Address string `ini:"address" required:"true" validate:"hostname" doc:"host name"`
+		Port int `ini:"port" default:"3306" validate:"min=1,max=65535"`
+		Password string `ini:"password" secret:"true"`
+		DSN string `ini:"dsn" default:"root:p$w%d@/db ; main"`
+		Hosts []string `ini:"hosts" default:"a,b"`
+		Timeout string `ini:"timeout"`
+	} `ini:"mysql" doc:"MySQL | primary"`
+	Options map[string]int `ini:"options"`
+}
+
+// TestWriteSample 示例文件中的默认值读回来和 default tag 一致, 没有默认值的可选键被注释掉
+func TestWriteSample(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteSample(&b, &schemaConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	sample := b.String()
+	for _, want := range []string{
+		"; application name\n; string, default: app\nname=app\n",
+		"; MySQL | primary\n[mysql]\n",
+		"; host name\n; string, required, validate: hostname\naddress=\n",
+		";password=\n",
+		";timeout=\n",
+		"[options]\n; any key, value type: int\n",
+	} {
+		if !strings.Contains(sample, want) {
+			t.Errorf("sample does not contain %q\n%s", want, sample)
+		}
+	}
+	// 必填的键留空, 填上之后示例文件就能直接加载
+	var cfg schemaConfig
+	filled := strings.Replace(sample, "address=\n", "address=db\n", 1)
+	if err := Unmarshal([]byte(filled), &cfg, Options{Strict: true}); err != nil {
+		t.Fatalf("%v\n%s", err, sample)
+	}
+	if cfg.Name != "app" || cfg.MySQL.Address != "db" || cfg.MySQL.Port != 3306 || cfg.MySQL.DSN != "root:p$w%d@/db ; main" || !reflect.DeepEqual(cfg.MySQL.Hosts, []string{"a", "b"}) {
+		t.Errorf("sample loads as %+v", cfg)
+	}
+}
+
+// TestWriteMarkdown 每个节一个表格, 单元格中的 | 被转义
+func TestWriteMarkdown(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteMarkdown(&b, &schemaConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	doc := b.String()
+	for _, want := range []string{
+		"## Global keys\n\n",
+		"## [mysql]\n\nMySQL \\| primary\n\n",
+		"| `address` | string |  | yes | `hostname` | host name |\n",
+		"| `port` | int | `3306` |  | `min=1,max=65535` |  |\n",
+		"| `password` | string |  |  |  | (secret, may be encrypted) |\n",
+		"## [options]\n",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("markdown does not contain %q\n%s", want, doc)
+		}
+	}
+	if err := WriteMarkdown(&b, 1); err == nil {
+		t.Error("expected an error for a non-struct")
+	}
+}
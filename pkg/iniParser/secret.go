@@ -0,0 +1,155 @@
+package iniparser
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// 加密的配置值, 带 secret tag 的字段可以写成 enc:v1:<base64>, 解析时用 AES-GCM 解密
+//   Password string `ini:"password" secret:"true"`
+//   password = enc:v1:3q2+7w...
+// base64 中是 12 字节的 nonce 和密文, 密钥是 base64 编码的 16, 24 或 32 字节
+// 密钥由 Options.Keys 提供, 没有设置时从环境变量 INI_SECRET_KEY 读取, 只有遇到加密的值才会读取
+// 不以 enc:v1: 开头的值按明文处理, 没有 secret tag 的字段不解密
+// 生成密钥和加密一个值, 不给出明文时从标准输入读取, 避免留在 shell 历史中:
+//   initool genkey > ini.key
+//   initool encrypt -keyfile ini.key 'my password'
+
+// secretPrefix 加密值的前缀, v1 表示 AES-GCM
+const secretPrefix = "enc:v1:"
+
+// DefaultKeyEnv 没有设置 Options.Keys 时保存密钥的环境变量
+const DefaultKeyEnv = "INI_SECRET_KEY"
+
+// KeyProvider 提供解密用的密钥
+type KeyProvider interface {
+	Key() ([]byte, error)
+}
+
+// KeyFile 从文件中读取 base64 编码的密钥
+type KeyFile string
+
+// Key 实现 KeyProvider
+func (f KeyFile) Key() ([]byte, error) {
+	b, err := os.ReadFile(string(f))
+	if err != nil {
+		return nil, err
+	}
+	return decodeKey(string(b), "key file "+string(f))
+}
+
+// KeyEnv 从环境变量中读取 base64 编码的密钥
+type KeyEnv string
+
+// Key 实现 KeyProvider
+func (e KeyEnv) Key() ([]byte, error) {
+	s, ok := os.LookupEnv(string(e))
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s is not set", string(e))
+	}
+	return decodeKey(s, "environment variable "+string(e))
+}
+
+// decodeKey 解码 base64 的密钥并检查长度, from 用于报错
+func decodeKey(s, from string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("%s: key should be base64: %v", from, err)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s: key should be 16, 24 or 32 bytes, got %d", from, len(key))
+}
+
+// GenerateKey 生成一个随机的 32 字节密钥, 返回 base64 编码
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// EncryptValue 用 provider 的密钥加密明文, 返回可以直接写进 ini 文件的 enc:v1: 值
+func EncryptValue(plaintext string, provider KeyProvider) (string, error) {
+	key, err := provider.Key()
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return secretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptValue 解密一个 enc:v1: 值
+func decryptValue(value string, key []byte) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, secretPrefix))
+	if err != nil {
+		return "", fmt.Errorf("incorrect encrypted value: %v", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("incorrect encrypted value: too short")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("decrypt failed: wrong key or corrupted value")
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// secrets 解析时解密带 secret tag 的字段的值, 密钥第一次用到时才读取
+type secrets struct {
+	provider KeyProvider
+	key []byte
+}
+
+func newSecrets(provider KeyProvider) *secrets {
+	if provider == nil {
+		provider = KeyEnv(DefaultKeyEnv)
+	}
+	return &secrets{provider: provider}
+}
+
+// value 返回字段实际的值, 不需要解密时原样返回
+func (s *secrets) value(value string, tag reflect.StructTag) (string, error) {
+	if tag.Get("secret") != "true" || !strings.HasPrefix(value, secretPrefix) {
+		return value, nil
+	}
+	if s.key == nil {
+		key, err := s.provider.Key()
+		if err != nil {
+			return "", err
+		}
+		s.key = key
+	}
+	return decryptValue(value, s.key)
+}
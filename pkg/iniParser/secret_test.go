@@ -0,0 +1,123 @@
+package iniparser
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type secretConfig struct {
+	MySQL struct {
+		Password string `ini:"password" secret:"true"`
+		Token string `ini:"token"`
+	} `ini:"mysql"`
+}
+
+// newKeyFile 生成一个密钥写到临时文件中, 同时返回 base64 编码的密钥
+func newKeyFile(t *testing.T) (KeyFile, string) {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := writeFiles(t, map[string]string{"ini.key": key + "\n"})
+	return KeyFile(filepath.Join(dir, "ini.key")), key
+}
+
+// loadSecret 用 provider 解析 [mysql] 中 password 和 token 为 value 的配置
+func loadSecret(t *testing.T, value string, provider KeyProvider) (secretConfig, error) {
+	t.Helper()
+	var cfg secretConfig
+	dir := writeFiles(t, map[string]string{"config.ini": "[mysql]\npassword=" + value + "\ntoken=" + value + "\n"})
+	err := LoadIni(filepath.Join(dir, "config.ini"), &cfg, Options{Keys: provider})
+	return cfg, err
+}
+
+// TestSecretRoundTrip 用文件和环境变量中的密钥加密再解密, 没有 secret tag 的字段保持原样
+func TestSecretRoundTrip(t *testing.T) {
+	keyFile, encoded := newKeyFile(t)
+	key, err := keyFile.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != 32 {
+		t.Errorf("generated a %d byte key, want 32", len(key))
+	}
+	t.Setenv("INI_TEST_KEY", " "+encoded+"\n")
+	t.Setenv(DefaultKeyEnv, encoded)
+	providers := map[string]KeyProvider{
+		"KeyFile": keyFile,
+		"KeyEnv": KeyEnv("INI_TEST_KEY"),
+		"default": nil,
+	}
+	for name, provider := range providers {
+		t.Run(name, func(t *testing.T) {
+			value, err := EncryptValue("s3cret$%", keyFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(value, secretPrefix) {
+				t.Fatalf("got %q, want the %s prefix", value, secretPrefix)
+			}
+			cfg, err := loadSecret(t, value, provider)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.MySQL.Password != "s3cret$%" {
+				t.Errorf("password = %q, want s3cret$%%", cfg.MySQL.Password)
+			}
+			if cfg.MySQL.Token != value {
+				t.Errorf("token = %q, want the encrypted value unchanged", cfg.MySQL.Token)
+			}
+		})
+	}
+}
+
+// TestSecretErrors 错误的密钥, 截断的值和读不到的密钥, 错误带有键的位置
+func TestSecretErrors(t *testing.T) {
+	keyFile, _ := newKeyFile(t)
+	otherKey, _ := newKeyFile(t)
+	value, err := EncryptValue("s3cret", keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("INI_TEST_SHORT_KEY", "c2hvcnQ=")
+	tests := []struct {
+		name string
+		value string
+		provider KeyProvider
+		want string
+	}{
+		{"wrong key", value, otherKey, "wrong key or corrupted value"},
+		{"truncated", value[:len(value)-8], keyFile, "wrong key or corrupted value"},
+		{"too short", secretPrefix + "AAAA", keyFile, "too short"},
+		{"not base64", secretPrefix + "!!", keyFile, "incorrect encrypted value"},
+		{"missing env", value, KeyEnv("INI_TEST_NO_SUCH_KEY"), "INI_TEST_NO_SUCH_KEY is not set"},
+		{"short key", value, KeyEnv("INI_TEST_SHORT_KEY"), "key should be 16, 24 or 32 bytes, got 5"},
+		{"missing file", value, KeyFile(filepath.Join(t.TempDir(), "none.key")), "none.key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadSecret(t, tt.value, tt.provider)
+			var pe *ParseError
+			if !errors.As(err, &pe) || pe.Line != 2 || pe.Key != "password" {
+				t.Fatalf("got %v, want a ParseError for password on line 2", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestSecretPlain 不以 enc:v1: 开头的值按明文处理, 用不到密钥时不读取
+func TestSecretPlain(t *testing.T) {
+	cfg, err := loadSecret(t, "plain", KeyEnv("INI_TEST_NO_SUCH_KEY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MySQL.Password != "plain" {
+		t.Errorf("password = %q, want plain", cfg.MySQL.Password)
+	}
+}
@@ -0,0 +1,88 @@
+package iniparser
+
+import (
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+)
+
+// 除了本地文件, 配置还可以来自 io.Reader, 字节切片和 fs.FS(如 embed.FS)
+//   err := Decode(os.Stdin, &cfg)
+//   err := Unmarshal(body, &cfg)
+//   err := LoadFS(configFS, "conf/config.ini", &cfg)
+// Decode 和 Unmarshal 没有所在的目录, 不支持 include
+
+// Decode 从 r 读取 ini 内容并赋给 data 指向的结构体
+func Decode(r io.Reader, data interface{}, opts ...Options) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return Unmarshal(b, data, opts...)
+}
+
+// Unmarshal 解析内容并赋给 data 指向的结构体, 格式由 Options.Format 指定, 默认是 ini
+func Unmarshal(b []byte, data interface{}, opts ...Options) error {
+	o := mergeOptions(opts)
+	doc, err := parseBytes(b, o.Format)
+	if err != nil {
+		return err
+	}
+	return decodeDocument(doc, data, o)
+}
+
+// LoadFS 从 fsys 中读取 name 并赋给 data 指向的结构体, include 的路径相对于 fsys 中的文件
+func LoadFS(fsys fs.FS, name string, data interface{}, opts ...Options) error {
+	o := mergeOptions(opts)
+	doc, err := readIncludes(fsSource{fsys}, name, o.Format, nil)
+	if err != nil {
+		return err
+	}
+	return decodeDocument(doc, data, o)
+}
+
+// fileSource 读取配置文件的地方, 本地文件系统或 fs.FS
+type fileSource interface {
+	readFile(name string) ([]byte, error)
+	// resolve 返回 from 中 include 的 name 的路径
+	resolve(from, name string) string
+	// id 返回文件的唯一标识, 用来检测循环引用
+	id(name string) (string, error)
+}
+
+// osSource 本地文件系统, 路径按操作系统的规则处理
+type osSource struct{}
+
+func (osSource) readFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(name)
+}
+
+func (osSource) resolve(from, name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(filepath.Dir(from), name)
+}
+
+func (osSource) id(name string) (string, error) {
+	return filepath.Abs(name)
+}
+
+// fsSource fs.FS 中的文件, 路径总是用 / 分隔
+type fsSource struct {
+	fsys fs.FS
+}
+
+func (s fsSource) readFile(name string) ([]byte, error) {
+	return fs.ReadFile(s.fsys, name)
+}
+
+func (fsSource) resolve(from, name string) string {
+	return path.Join(path.Dir(from), name)
+}
+
+func (fsSource) id(name string) (string, error) {
+	return path.Clean(name), nil
+}
@@ -0,0 +1,234 @@
+package iniparser
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// 通过 validate tag 声明的校验规则, 多个规则用逗号分隔
+//   Port int `ini:"port" validate:"min=1,max=65535"`
+//   Mode string `ini:"mode" validate:"oneof=dev test prod"`
+//   Address string `ini:"address" validate:"hostname"`
+// 支持的规则:
+//   min=N, max=N  数字比较大小, 字符串比较长度, time.Duration 可以写成 min=1s
+//   oneof=a b c   值必须是空格分隔的选项之一
+//   regex=expr    值必须匹配正则, 必须放在最后, 之后的内容(包括逗号)都属于正则
+//   hostname      值必须是合法的主机名(RFC 1123)
+//   host          值必须是主机名或 IP 地址, IPv6 地址不带方括号, 如 ::1
+//   ip            值必须是合法的 IPv4 或 IPv6 地址
+// 切片字段的规则作用在每个元素上, 指针字段为 nil 时不校验
+
+// FieldError 一个字段违反校验规则的错误
+type FieldError struct {
+	// File 键所在的文件, 值来自默认值时为空
+	File string
+	// Line 键所在的行号, 值来自默认值时为 0
+	Line int
+	Section string
+	Key string
+	Value string
+	Rule string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s.%s: value \"%s\" violates %s", position{e.File, e.Line}, e.Section, e.Key, e.Value, e.Rule)
+}
+
+// ValidationErrors 收集到的所有校验错误, 一次性返回
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Error())
+	}
+	return fmt.Sprintf("%d validation error(s):\n%s", len(e), strings.Join(msgs, "\n"))
+}
+
+// hostnameRegexp RFC 1123 主机名, 每段 1-63 个字母数字或连字符, 不以连字符开头结尾
+var hostnameRegexp = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// regexCache regex 规则编译好的正则, 以正则表达式为键, 每个 tag 只编译一次
+var regexCache sync.Map
+
+// compileRegex 从 regexCache 中取出正则, 没有时编译并缓存
+func compileRegex(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(expr, re)
+	return re, nil
+}
+
+// validateStruct 按 validate tag 校验结构体, lines 是解析时每个键所在的位置
+func validateStruct(v reflect.Value, lines map[string]position) error {
+	var errs ValidationErrors
+	if err := collectViolations(v, nil, lines, &errs); err != nil {
+		return err
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// collectViolations 递归收集违反规则的字段, 文件中没有出现的可选节(nil 的结构体指针)不检查
+// 返回的 error 表示 tag 本身写错了
+func collectViolations(v reflect.Value, path []string, lines map[string]position, errs *ValidationErrors) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("ini")
+		if len(name) == 0 {
+			continue
+		}
+		fieldObj := v.Field(i)
+		if isSectionType(fieldObj.Type()) && fieldObj.Kind() == reflect.Ptr {
+			if fieldObj.IsNil() {
+				continue
+			}
+			fieldObj = fieldObj.Elem()
+		}
+		if isSectionType(fieldObj.Type()) && fieldObj.Kind() == reflect.Struct {
+			if err := collectViolations(fieldObj, append(path[:len(path):len(path)], name), lines, errs); err != nil {
+				return err
+			}
+			continue
+		}
+		rules, ok := field.Tag.Lookup("validate")
+		if !ok {
+			continue
+		}
+		// nil 指针表示没有设置, 不校验
+		if fieldObj.Kind() == reflect.Ptr {
+			if fieldObj.IsNil() {
+				continue
+			}
+			fieldObj = fieldObj.Elem()
+		}
+		values := []reflect.Value{fieldObj}
+		if fieldObj.Kind() == reflect.Slice && !isValueType(fieldObj.Type()) {
+			values = values[:0]
+			for j := 0; j < fieldObj.Len(); j++ {
+				values = append(values, fieldObj.Index(j))
+			}
+		}
+		for _, value := range values {
+			rule, err := checkRules(value, rules, field.Tag)
+			if err != nil {
+				return fmt.Errorf("field %s: %v", field.Name, err)
+			}
+			if len(rule) == 0 {
+				continue
+			}
+			str, _ := formatValue(value, field.Tag)
+			pos := lines[sectionKey(path, name)]
+			*errs = append(*errs, &FieldError{
+				File: pos.file,
+				Line: pos.line,
+				Section: strings.Join(path, "."),
+				Key: name,
+				Value: str,
+				Rule: rule,
+			})
+		}
+	}
+	return nil
+}
+
+// checkRules 用逗号分隔的规则逐个校验值, 返回第一条违反的规则, 全部通过时返回空字符串
+func checkRules(value reflect.Value, rules string, tag reflect.StructTag) (string, error) {
+	for len(rules) > 0 {
+		var rule string
+		// regex 中可能有逗号, 它之后的内容都属于正则
+		if strings.HasPrefix(rules, "regex=") {
+			rule, rules = rules, ""
+		} else if i := strings.Index(rules, ","); i != -1 {
+			rule, rules = rules[:i], rules[i+1:]
+		} else {
+			rule, rules = rules, ""
+		}
+		rule = strings.TrimSpace(rule)
+		if len(rule) == 0 {
+			continue
+		}
+		ok, err := checkRule(value, rule, tag)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return rule, nil
+		}
+	}
+	return "", nil
+}
+
+// checkRule 校验单条规则
+func checkRule(value reflect.Value, rule string, tag reflect.StructTag) (bool, error) {
+	name, arg := rule, ""
+	if i := strings.Index(rule, "="); i != -1 {
+		name, arg = rule[:i], rule[i+1:]
+	}
+	str, _ := formatValue(value, tag)
+	switch name {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		// time.Duration 的范围可以写成 min=1s
+		if value.Type() == durationType {
+			var d time.Duration
+			d, err = time.ParseDuration(arg)
+			limit = float64(d)
+		}
+		if err != nil {
+			return false, fmt.Errorf("incorrect rule - \"%s\"", rule)
+		}
+		var n float64
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = float64(value.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			n = float64(value.Uint())
+		case reflect.Float32, reflect.Float64:
+			n = value.Float()
+		case reflect.String:
+			n = float64(utf8.RuneCountInString(value.String()))
+		default:
+			return false, fmt.Errorf("rule %s is not supported for %s", name, value.Type())
+		}
+		if name == "min" {
+			return n >= limit, nil
+		}
+		return n <= limit, nil
+	case "oneof":
+		for _, option := range strings.Fields(arg) {
+			if str == option {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "regex":
+		re, err := compileRegex(arg)
+		if err != nil {
+			return false, fmt.Errorf("incorrect rule - \"%s\": %v", rule, err)
+		}
+		return re.MatchString(str), nil
+	case "hostname":
+		return len(str) <= 253 && hostnameRegexp.MatchString(str), nil
+	case "ip":
+		return net.ParseIP(str) != nil, nil
+	case "host":
+		return net.ParseIP(str) != nil || len(str) <= 253 && hostnameRegexp.MatchString(str), nil
+	}
+	return false, fmt.Errorf("unknown rule - \"%s\"", rule)
+}
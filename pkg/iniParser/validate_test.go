@@ -0,0 +1,124 @@
+package iniparser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCheckRule 每条规则单独校验
+func TestCheckRule(t *testing.T) {
+	cases := []struct {
+		value interface{}
+		rule string
+		want bool
+	}{
+		{3306, "min=1", true},
+		{0, "min=1", false},
+		{65536, "max=65535", false},
+		{1.5, "max=1.5", true},
+		{"abc", "min=3", true},
+		{"ab", "min=3", false},
+		{"中文字", "max=3", true},
+		{"prod", "oneof=dev test prod", true},
+		{"staging", "oneof=dev test prod", false},
+		{"utf8mb4", "regex=^utf8(mb4)?$", true},
+		{"latin1", "regex=^utf8(mb4)?$", false},
+		{"db-1.example.com", "hostname", true},
+		{"-db.example.com", "hostname", false},
+		{"db_1", "hostname", false},
+		{"10.20.30.40", "ip", true},
+		{"::1", "ip", true},
+		{"10.20.30.400", "ip", false},
+		{"db-1.example.com", "host", true},
+		{"::1", "host", true},
+		{"[::1]", "host", false},
+		{"db_1", "host", false},
+	}
+	for _, c := range cases {
+		got, err := checkRule(reflect.ValueOf(c.value), c.rule, "")
+		if err != nil {
+			t.Errorf("%v %s: %v", c.value, c.rule, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%v %s = %v, want %v", c.value, c.rule, got, c.want)
+		}
+	}
+	// 写错的规则返回 error 而不是校验失败
+	for _, rule := range []string{"min=x", "regex=(", "unknown"} {
+		if _, err := checkRule(reflect.ValueOf("a"), rule, ""); err == nil {
+			t.Errorf("%s: expected an error", rule)
+		}
+	}
+	if _, err := checkRule(reflect.ValueOf(true), "min=1", ""); err == nil {
+		t.Error("min on a bool should be an error")
+	}
+}
+
+// TestCheckRulesRegexComma regex 规则中的逗号属于正则, 不会被当成规则分隔符
+func TestCheckRulesRegexComma(t *testing.T) {
+	rule, err := checkRules(reflect.ValueOf("aaa"), "min=1,regex=^a{2,3}$", "")
+	if err != nil || len(rule) != 0 {
+		t.Errorf("got %q, %v", rule, err)
+	}
+	rule, err = checkRules(reflect.ValueOf("aaaa"), "min=1,regex=^a{2,3}$", "")
+	if err != nil || rule != "regex=^a{2,3}$" {
+		t.Errorf("got %q, %v", rule, err)
+	}
+}
+
+// TestCompileRegexCache 同一个正则只编译一次
+func TestCompileRegexCache(t *testing.T) {
+	re1, err := compileRegex("^cache-[0-9]+$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	re2, _ := compileRegex("^cache-[0-9]+$")
+	if re1 != re2 {
+		t.Error("the compiled regexp should be cached")
+	}
+}
+
+// TestLoadIniValidate 所有违反规则的字段一次返回, 带上文件名和行号
+func TestLoadIniValidate(t *testing.T) {
+	type server struct {
+		Address string `ini:"address" validate:"hostname"`
+		Port int `ini:"port" default:"0" validate:"min=1,max=65535"`
+		Mode string `ini:"mode" validate:"oneof=dev prod"`
+		Hosts []string `ini:"hosts" validate:"ip"`
+	}
+	type config struct {
+		Server server `ini:"server"`
+	}
+	err := loadString(t, "[server]\naddress=bad_host\nmode=prod\nhosts=10.0.0.1, 10.0.0.x\n", &config{})
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", errs)
+	}
+	want := []struct {
+		line int
+		key string
+		rule string
+	}{
+		{2, "address", "hostname"},
+		{0, "port", "min=1"},
+		{4, "hosts", "ip"},
+	}
+	for i, w := range want {
+		fe := errs[i]
+		if fe.Line != w.line || fe.Section != "server" || fe.Key != w.key || fe.Rule != w.rule {
+			t.Errorf("error %d = %+v, want line %d %s %s", i, fe, w.line, w.key, w.rule)
+		}
+	}
+	if !strings.Contains(errs[0].Error(), "config.ini:2: server.address") {
+		t.Errorf("message should contain the position, got %q", errs[0].Error())
+	}
+	if err = loadString(t, "[server]\naddress=db.local\nport=3306\nmode=dev\n", &config{}); err != nil {
+		t.Errorf("valid config failed: %v", err)
+	}
+}
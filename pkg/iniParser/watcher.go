@@ -0,0 +1,324 @@
+package iniparser
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 热加载: 定时检查配置文件和 include 的文件, 任何一个变化后重新加载到一个新的结构体里, 成功后原子地替换
+//   w, err := NewWatcher("config.ini", 5*time.Second, func() interface{} { return new(Config) }, Options{Strict: true})
+//   w.OnChange(func(changes []Change) { ... })
+//   w.Start()
+//   defer w.Stop()
+//   cfg := w.Config().(*Config)
+// 重新加载失败(包括校验失败)时保留上一次成功的配置, 并调用 OnError 注册的回调
+// 回调在 Watcher 的 goroutine 中按注册顺序调用
+// 每次加载后按新的 include 关系更新要检查的文件, 新 include 的文件也会被监视
+
+// Change 一个发生变化的键, 新增的键 Old 为空, 删除的键 New 为空
+// 敏感字段(见 Redacted)的值是 ******, 不会出现在日志中
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+// Watcher 监视配置文件并热加载
+type Watcher struct {
+	fileName string
+	interval time.Duration
+	newConfig func() interface{}
+	// opts 每次加载使用的选项, 和 LoadIni 的一样
+	opts Options
+	// current 当前生效的配置, 保存 newConfig 返回的指针
+	current atomic.Value
+	// reloading 保证同一时间只有一次 Reload, 变化列表才和替换的顺序一致
+	reloading sync.Mutex
+
+	mu sync.Mutex
+	// files 配置文件和 include 的文件上次检查时的状态
+	files map[string]fileState
+	onChange []func([]Change)
+	onError []func(error)
+	stop chan struct{}
+	done chan struct{}
+}
+
+// fileState 文件的修改时间和大小, 任何一个变化就认为文件变了
+type fileState struct {
+	modTime time.Time
+	size int64
+}
+
+// NewWatcher 加载一次配置文件并返回 Watcher, newConfig 每次返回一个新的结构体指针
+// interval 必须大于 0, opts 可选, 每次重新加载都使用它, 第一次加载失败时返回错误
+func NewWatcher(fileName string, interval time.Duration, newConfig func() interface{}, opts ...Options) (*Watcher, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("watch interval should be positive, got %v", interval)
+	}
+	w := &Watcher{
+		fileName: fileName,
+		interval: interval,
+		newConfig: newConfig,
+		opts: mergeOptions(opts),
+		files: make(map[string]fileState),
+	}
+	data := newConfig()
+	files, err := w.load(data)
+	if err != nil {
+		return nil, err
+	}
+	w.watch(files)
+	w.current.Store(data)
+	return w, nil
+}
+
+// load 读取配置文件和 include 的文件并解析到 data, 返回读到的所有文件
+func (w *Watcher) load(data interface{}) ([]string, error) {
+	doc, err := readDocument(w.fileName, w.opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	return documentFiles(doc), decodeDocument(doc, data, w.opts)
+}
+
+// documentFiles 按出现的顺序返回文档中的节来自的文件, 每个文件至少有一个全局节
+func documentFiles(doc *Document) []string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, sec := range doc.Sections() {
+		if !seen[sec.File] {
+			seen[sec.File] = true
+			files = append(files, sec.File)
+		}
+	}
+	return files
+}
+
+// watch 把要检查的文件换成 files, 已经在检查的文件保留上次的状态, 新的文件记下当前的状态
+func (w *Watcher) watch(files []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	watched := make(map[string]fileState, len(files))
+	for _, name := range files {
+		state, ok := w.files[name]
+		if !ok {
+			if info, err := os.Stat(name); err == nil {
+				state = fileState{modTime: info.ModTime(), size: info.Size()}
+			}
+		}
+		watched[name] = state
+	}
+	w.files = watched
+}
+
+// Config 返回当前生效的配置, 可以在多个 goroutine 中并发调用, 返回的结构体不要修改
+func (w *Watcher) Config() interface{} {
+	return w.current.Load()
+}
+
+// OnChange 注册配置变化后的回调, 参数是按键名排序的变化列表
+func (w *Watcher) OnChange(fn func([]Change)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onChange = append(w.onChange, fn)
+}
+
+// OnError 注册重新加载失败时的回调
+func (w *Watcher) OnError(fn func(error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = append(w.onError, fn)
+}
+
+// Start 启动后台 goroutine, 每隔 interval 检查一次文件
+func (w *Watcher) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		return
+	}
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	go w.run(w.stop, w.done)
+}
+
+// Stop 停止后台 goroutine 并等待它退出
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	stop, done := w.stop, w.done
+	w.stop, w.done = nil, nil
+	w.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (w *Watcher) run(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+// check 任何一个文件的修改时间或大小变化时重新加载
+// 文件被删除或读不到时也算变化, 由 Reload 报告错误并按新的 include 关系更新要检查的文件
+func (w *Watcher) check() {
+	w.mu.Lock()
+	names := make([]string, 0, len(w.files))
+	for name := range w.files {
+		names = append(names, name)
+	}
+	w.mu.Unlock()
+	states := make(map[string]fileState, len(names))
+	for _, name := range names {
+		var state fileState
+		if info, err := os.Stat(name); err == nil {
+			state = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+		states[name] = state
+	}
+	changed := false
+	w.mu.Lock()
+	for name, state := range states {
+		if old := w.files[name]; !old.modTime.Equal(state.modTime) || old.size != state.size {
+			changed = true
+		}
+		w.files[name] = state
+	}
+	w.mu.Unlock()
+	if changed {
+		w.Reload()
+	}
+}
+
+// Reload 立即重新加载配置文件, 失败时保留原来的配置并返回错误
+func (w *Watcher) Reload() error {
+	w.reloading.Lock()
+	defer w.reloading.Unlock()
+	data := w.newConfig()
+	files, err := w.load(data)
+	// 解析失败时 include 关系也可能变了, 只要读到了文件就更新要检查的文件
+	if len(files) > 0 {
+		w.watch(files)
+	}
+	if err != nil {
+		w.fail(err)
+		return err
+	}
+	old := w.current.Load()
+	w.current.Store(data)
+	changes := diffConfig(old, data)
+	if len(changes) == 0 {
+		return nil
+	}
+	w.mu.Lock()
+	callbacks := append([]func([]Change){}, w.onChange...)
+	w.mu.Unlock()
+	for _, fn := range callbacks {
+		fn(changes)
+	}
+	return nil
+}
+
+// fail 调用 OnError 注册的回调
+func (w *Watcher) fail(err error) {
+	w.mu.Lock()
+	callbacks := append([]func(error){}, w.onError...)
+	w.mu.Unlock()
+	for _, fn := range callbacks {
+		fn(err)
+	}
+}
+
+// diffConfig 比较两个配置结构体, 返回值不同的键
+// 用真实的值比较, 只改了密码也会报告, 但变化中的值和 Redacted 一样隐藏了敏感字段
+func diffConfig(oldConfig, newConfig interface{}) []Change {
+	changes := DiffKeys(flattenConfig(oldConfig), flattenConfig(newConfig))
+	oldShown, newShown := flattenConfig(Redacted(oldConfig)), flattenConfig(Redacted(newConfig))
+	for i, c := range changes {
+		changes[i].Old, changes[i].New = oldShown[c.Key], newShown[c.Key]
+	}
+	return changes
+}
+
+// DiffKeys 比较两组 section.key 到值的映射, 返回按键名排序的变化
+func DiffKeys(oldKeys, newKeys map[string]string) []Change {
+	var changes []Change
+	for key, value := range newKeys {
+		if oldValue, ok := oldKeys[key]; !ok || oldValue != value {
+			changes = append(changes, Change{Key: key, Old: oldValue, New: value})
+		}
+	}
+	for key, value := range oldKeys {
+		if _, ok := newKeys[key]; !ok {
+			changes = append(changes, Change{Key: key, Old: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// unescaper EscapeValue 的逆操作, 把 $$ 和 %% 还原成 $ 和 %
+var unescaper = strings.NewReplacer("$$", "$", "%%", "%")
+
+// flattenConfig 把配置结构体展开成 section.key 到值的映射, 值是字段的字面值, 切片的元素用逗号连起来
+func flattenConfig(data interface{}) map[string]string {
+	keys := make(map[string]string)
+	b, err := MarshalIni(data)
+	if err != nil {
+		return keys
+	}
+	doc, err := ParseDocument(b)
+	if err != nil {
+		return keys
+	}
+	// SaveIni 写出时转义了 $ 和 %, 还原之后才是字段的值
+	for name, value := range doc.Flatten() {
+		keys[name] = unescaper.Replace(value)
+	}
+	return keys
+}
+
+// Flatten 把文档展开成 section.key 到原始值的映射, 不展开变量
+// 重复的键(切片)用逗号连起来, include 不算作键
+func (doc *Document) Flatten() map[string]string {
+	keys := make(map[string]string)
+	for _, sec := range doc.Sections() {
+		var path []string
+		if len(sec.Name) > 0 {
+			path = sectionPath(sec.Name)
+		}
+		// seen 本节中出现过的键, 同一个节中重复的键才连起来, 不同节中的以后出现的为准
+		seen := make(map[string]bool)
+		for _, k := range sec.Keys() {
+			if len(sec.Name) == 0 && k.Name == includeKey {
+				continue
+			}
+			value := k.Value
+			name := sectionKey(path, k.Name)
+			if seen[name] {
+				value = keys[name] + "," + value
+			}
+			seen[name] = true
+			keys[name] = value
+		}
+	}
+	return keys
+}
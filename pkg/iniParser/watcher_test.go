@@ -0,0 +1,162 @@
+package iniparser
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type watcherConfig struct {
+	MySQL struct {
+		Address string `ini:"address"`
+		Port int `ini:"port"`
+		Password string `ini:"password"`
+		Database string `ini:"database"`
+	} `ini:"mysql"`
+}
+
+func newWatcherConfig() interface{} {
+	return new(watcherConfig)
+}
+
+// TestWatcherIncludes 只修改 include 的文件也要重新加载, 变化列表中是字面值, 密码被隐藏
+func TestWatcherIncludes(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.ini": "[mysql]\naddress=db\nport=1\npassword=pw1\ndatabase=old$$1\n",
+		"config.ini": "include=base.ini\n\n[mysql]\naddress=main-db\n",
+	})
+	w, err := NewWatcher(filepath.Join(dir, "config.ini"), time.Second, newWatcherConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes []Change
+	w.OnChange(func(c []Change) { changes = c })
+	w.OnError(func(err error) { t.Error(err) })
+	if cfg := w.Config().(*watcherConfig); cfg.MySQL.Address != "main-db" || cfg.MySQL.Port != 1 {
+		t.Fatalf("got %+v", cfg.MySQL)
+	}
+	writeFile(t, filepath.Join(dir, "base.ini"), "[mysql]\naddress=db\nport=3306\npassword=pw2\ndatabase=new%%1\n")
+	w.check()
+	if cfg := w.Config().(*watcherConfig); cfg.MySQL.Port != 3306 || cfg.MySQL.Password != "pw2" || cfg.MySQL.Database != "new%1" {
+		t.Errorf("got %+v after editing the included file", cfg.MySQL)
+	}
+	want := []Change{
+		{Key: "mysql.database", Old: "old$1", New: "new%1"},
+		{Key: "mysql.password", Old: "******", New: "******"},
+		{Key: "mysql.port", Old: "1", New: "3306"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got changes %v, want %v", changes, want)
+	}
+}
+
+// TestWatcherMissingFile 文件被删除时报告错误并保留原来的配置, 文件恢复后重新加载
+func TestWatcherMissingFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.ini": "[mysql]\nport=1\n",
+		"config.ini": "include=base.ini\n",
+	})
+	w, err := NewWatcher(filepath.Join(dir, "config.ini"), time.Second, newWatcherConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []error
+	w.OnError(func(err error) { errs = append(errs, err) })
+	// 去掉 include 后删除被引入的文件, 重新加载后不再检查它
+	writeFile(t, filepath.Join(dir, "config.ini"), "[mysql]\nport=2\n")
+	if err = os.Remove(filepath.Join(dir, "base.ini")); err != nil {
+		t.Fatal(err)
+	}
+	w.check()
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	if cfg := w.Config().(*watcherConfig); cfg.MySQL.Port != 2 {
+		t.Errorf("got %+v, want port 2", cfg.MySQL)
+	}
+	// 删除配置文件本身时报告错误, 保留原来的配置
+	if err = os.Remove(filepath.Join(dir, "config.ini")); err != nil {
+		t.Fatal(err)
+	}
+	w.check()
+	if len(errs) != 1 {
+		t.Fatalf("got errors %v, want one", errs)
+	}
+	if cfg := w.Config().(*watcherConfig); cfg.MySQL.Port != 2 {
+		t.Errorf("got %+v, want the previous config", cfg.MySQL)
+	}
+	writeFile(t, filepath.Join(dir, "config.ini"), "[mysql]\nport=3\n")
+	w.check()
+	if cfg := w.Config().(*watcherConfig); cfg.MySQL.Port != 3 {
+		t.Errorf("got %+v, want port 3 after restoring the file", cfg.MySQL)
+	}
+}
+
+// TestNewWatcherInterval 检查间隔必须大于 0
+func TestNewWatcherInterval(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"config.ini": "[mysql]\nport=1\n"})
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if _, err := NewWatcher(filepath.Join(dir, "config.ini"), interval, newWatcherConfig); err == nil {
+			t.Errorf("interval %v: expected an error", interval)
+		}
+	}
+}
+
+// writeFile 覆盖写入文件, 并把修改时间往后调, 避免和上一次写入落在同一个时间戳上
+func writeFile(t *testing.T, fileName, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(fileName, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(fileName, later, later); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestWatcherOptions 重新加载时使用 NewWatcher 的选项
+func TestWatcherOptions(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"config.ini": "[MYSQL]\nport=1\n"})
+	fileName := filepath.Join(dir, "config.ini")
+	w, err := NewWatcher(fileName, time.Second, newWatcherConfig, Options{Strict: true, CaseInsensitiveKeys: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []error
+	w.OnError(func(err error) { errs = append(errs, err) })
+	writeFile(t, fileName, "[MYSQL]\nport=2\ntimeout=5\n")
+	w.check()
+	if len(errs) != 1 || !errors.Is(errs[0], ErrUnknownKey) {
+		t.Errorf("got errors %v, want an unknown key", errs)
+	}
+	if cfg := w.Config().(*watcherConfig); cfg.MySQL.Port != 1 {
+		t.Errorf("got %+v, want the previous config", cfg.MySQL)
+	}
+}
+
+// TestWatcherProfile 重新加载时仍然使用选中的环境
+func TestWatcherProfile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.ini": "[mysql]\naddress=db\nport=1\n",
+		"config.ini": "include=base.ini\n\n[mysql@prod : mysql]\naddress=prod-db\n",
+	})
+	w, err := NewWatcher(filepath.Join(dir, "config.ini"), time.Second, newWatcherConfig, Options{Profile: "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes []Change
+	w.OnChange(func(c []Change) { changes = c })
+	w.OnError(func(err error) { t.Error(err) })
+	writeFile(t, filepath.Join(dir, "base.ini"), "[mysql]\naddress=db\nport=3306\n")
+	w.check()
+	if cfg := w.Config().(*watcherConfig); cfg.MySQL.Address != "prod-db" || cfg.MySQL.Port != 3306 {
+		t.Errorf("got %+v, want the prod profile with the new port", cfg.MySQL)
+	}
+	if len(changes) != 1 || changes[0].Key != "mysql.port" {
+		t.Errorf("got changes %v, want mysql.port", changes)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
+	iniparser "github.com/pastaTree/goExercise/pkg/iniParser"
 )
 
 var db *sql.DB
@@ -14,10 +15,24 @@ type user struct {
 	age int
 }
 
+// config 配置文件中用到的节, 连接信息和连接池的大小都在 config.ini 中, 密码从环境变量 MYSQL_PASSWORD 读取
+type config struct {
+	MySQL iniparser.MySQLConfig `ini:"mysql"`
+}
+
 // 初始化数据库
 func initDB() (err error) {
+	// 读取配置, 生成连接串
+	var cfg config
+	err = iniparser.LoadIni("./config.ini", &cfg)
+	if err != nil {
+		return err
+	}
+	dsn, err := cfg.MySQL.DSN()
+	if err != nil {
+		return err
+	}
 	// 连接数据库
-	dsn := "root:Bristol123395@tcp(127.0.0.1:3306)/goDB"
 	db, err = sql.Open("mysql", dsn)
 	if err != nil {
 		return err
@@ -26,8 +41,7 @@ func initDB() (err error) {
 	if err != nil {
 		return err
 	}
-	db.SetConnMaxLifetime(10)
-	db.SetMaxIdleConns(5)
+	cfg.MySQL.SetPool(db)
 	fmt.Println("连接数据库成功!")
 	return
 }
@@ -181,7 +195,8 @@ func transaction() {
 func main() {
 	err := initDB()
 	if err != nil {
-		fmt.Printf("init DB failed, error: %#v\n", err)
+		fmt.Printf("init DB failed, error: %v\n", err)
+		return
 	}
 	//queryOne(1)
 	//queryMore(0)